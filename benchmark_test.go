@@ -1,6 +1,7 @@
 package gorouter
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -34,10 +35,9 @@ func benchmarkStatic(t int, b *testing.B) {
 
 func benchmarkWildcard(t int, b *testing.B) {
 	var path, rpath string
-	part := "/{x}"
 	rpart := "/x"
 	for i := 0; i < t; i++ {
-		path += part
+		path += fmt.Sprintf("/{x%d}", i)
 		rpath += rpart
 	}
 
@@ -60,10 +60,9 @@ func benchmarkWildcard(t int, b *testing.B) {
 
 func benchmarkRegexp(t int, b *testing.B) {
 	var path, rpath string
-	part := "/{x:r([a-z]+)go}"
 	rpart := "/rxgo"
 	for i := 0; i < t; i++ {
-		path += part
+		path += fmt.Sprintf("/{x%d:r([a-z]+)go}", i)
 		rpath += rpart
 	}
 
@@ -102,6 +101,100 @@ func BenchmarkNetHTTP(b *testing.B) {
 	})
 }
 
+func BenchmarkNetHTTPMiddleware(b *testing.B) {
+	next := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	s := New(next)
+	s.GET("/x/y", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	s.USE(http.MethodGet, "/", next)
+	s.USE(http.MethodGet, "/x", next)
+	s.USE(http.MethodGet, "/x/y", next)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/x/y", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.ServeHTTP(w, req)
+		}
+	})
+}
+
+// BenchmarkNetHTTPParams makes no allocations,
+// request copy and its context storing Params are pooled
+func BenchmarkNetHTTPParams(b *testing.B) {
+	s := New()
+	s.GET("/users/{id}/posts/{post:[0-9]+}", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/users/x/posts/1", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.ServeHTTP(w, req)
+		}
+	})
+}
+
+func benchmarkStaticRoutes(compile bool, b *testing.B) {
+	s := New()
+	for i := 0; i < 100; i++ {
+		s.GET(fmt.Sprintf("/api/v1/resource%d/status", i), http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	}
+	if compile {
+		s.Compile()
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/resource99/status", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.ServeHTTP(w, req)
+		}
+	})
+}
+
+func BenchmarkStaticRoutes(b *testing.B)         { benchmarkStaticRoutes(false, b) }
+func BenchmarkStaticRoutesCompiled(b *testing.B) { benchmarkStaticRoutes(true, b) }
+
+// BenchmarkRegister registers large route set, each registration copies only Nodes
+// on the route path and composes the route chain, so it does not slow down as the Tree grows
+func BenchmarkRegister(b *testing.B) {
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+	patterns := make([]string, 4000)
+	for i := range patterns {
+		patterns[i] = fmt.Sprintf("/admin/res%d/{id}/items", i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := New()
+		for _, pattern := range patterns {
+			s.GET(pattern, handler)
+		}
+	}
+}
+
 func BenchmarkStatic1(b *testing.B)  { benchmarkStatic(1, b) }
 func BenchmarkStatic2(b *testing.B)  { benchmarkStatic(2, b) }
 func BenchmarkStatic3(b *testing.B)  { benchmarkStatic(3, b) }
@@ -145,10 +238,9 @@ func benchmarkFastHTTPStatic(t int, b *testing.B) {
 
 func benchmarkFastHTTPWildcard(t int, b *testing.B) {
 	var path, rpath string
-	part := "/{x}"
 	rpart := "/x"
 	for i := 0; i < t; i++ {
-		path += part
+		path += fmt.Sprintf("/{x%d}", i)
 		rpath += rpart
 	}
 
@@ -167,10 +259,9 @@ func benchmarkFastHTTPWildcard(t int, b *testing.B) {
 
 func benchmarkFastHTTPRegexp(t int, b *testing.B) {
 	var path, rpath string
-	part := "/{x:r([a-z]+)go}"
 	rpart := "/rxgo"
 	for i := 0; i < t; i++ {
-		path += part
+		path += fmt.Sprintf("/{x%d:r([a-z]+)go}", i)
 		rpath += rpart
 	}
 
@@ -201,6 +292,68 @@ func BenchmarkFastHTTP(b *testing.B) {
 	})
 }
 
+func BenchmarkFastHTTPMiddleware(b *testing.B) {
+	next := func(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			h(ctx)
+		}
+	}
+
+	s := NewFastHTTPRouter(next)
+	s.GET("/x/y", func(_ *fasthttp.RequestCtx) {})
+	s.USE(fasthttp.MethodGet, "/", next)
+	s.USE(fasthttp.MethodGet, "/x", next)
+	s.USE(fasthttp.MethodGet, "/x/y", next)
+
+	ctx := buildFastHTTPRequestContext(http.MethodGet, "/x/y")
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.HandleFastHTTP(ctx)
+		}
+	})
+}
+
+// BenchmarkFastHTTPParams makes no allocations,
+// parameter values refer to pooled copy of request path
+func BenchmarkFastHTTPParams(b *testing.B) {
+	s := NewFastHTTPRouter()
+	s.GET("/users/{id}/posts/{post:[0-9]+}", func(_ *fasthttp.RequestCtx) {})
+
+	ctx := buildFastHTTPRequestContext(http.MethodGet, "/users/x/posts/1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.HandleFastHTTP(ctx)
+		}
+	})
+}
+
+func benchmarkFastHTTPStaticRoutes(compile bool, b *testing.B) {
+	s := NewFastHTTPRouter()
+	for i := 0; i < 100; i++ {
+		s.GET(fmt.Sprintf("/api/v1/resource%d/status", i), func(_ *fasthttp.RequestCtx) {})
+	}
+	if compile {
+		s.Compile()
+	}
+
+	ctx := buildFastHTTPRequestContext(http.MethodGet, "/api/v1/resource99/status")
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.HandleFastHTTP(ctx)
+		}
+	})
+}
+
+func BenchmarkFastHTTPStaticRoutes(b *testing.B)         { benchmarkFastHTTPStaticRoutes(false, b) }
+func BenchmarkFastHTTPStaticRoutesCompiled(b *testing.B) { benchmarkFastHTTPStaticRoutes(true, b) }
+
 func BenchmarkFastHTTPStatic1(b *testing.B)  { benchmarkFastHTTPStatic(1, b) }
 func BenchmarkFastHTTPStatic2(b *testing.B)  { benchmarkFastHTTPStatic(2, b) }
 func BenchmarkFastHTTPStatic3(b *testing.B)  { benchmarkFastHTTPStatic(3, b) }
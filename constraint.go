@@ -0,0 +1,161 @@
+package gorouter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+type constraintKind uint8
+
+const (
+	headerConstraint constraintKind = iota
+	queryConstraint
+	schemeConstraint
+	contentTypeConstraint
+)
+
+// constraint is a requirement request has to satisfy for the route to be selected
+type constraint struct {
+	kind  constraintKind
+	key   string
+	value string
+}
+
+// WithHeader requires request header to be equal to value,
+// empty value requires header to be present
+func WithHeader(key, value string) RouteOption {
+	return withConstraint(constraint{kind: headerConstraint, key: http.CanonicalHeaderKey(key), value: value})
+}
+
+// WithQuery requires request query parameter to be equal to value,
+// empty value requires parameter to be present
+func WithQuery(key, value string) RouteOption {
+	return withConstraint(constraint{kind: queryConstraint, key: key, value: value})
+}
+
+// WithScheme requires request to be made with given scheme, e.g. https
+func WithScheme(scheme string) RouteOption {
+	return withConstraint(constraint{kind: schemeConstraint, value: strings.ToLower(scheme)})
+}
+
+// WithContentType requires request body to be of given media type, e.g. application/json
+// requests not satisfying only this constraint are replied with 415 Error code
+func WithContentType(contentType string) RouteOption {
+	return withConstraint(constraint{kind: contentTypeConstraint, value: contentType})
+}
+
+// String describes constraint, e.g. header X-Api-Version=2
+func (c constraint) String() string {
+	switch c.kind {
+	case headerConstraint:
+		return "header " + constraintValue(c.key, c.value)
+	case queryConstraint:
+		return "query " + constraintValue(c.key, c.value)
+	case schemeConstraint:
+		return "scheme " + c.value
+	case contentTypeConstraint:
+		return "content-type " + c.value
+	}
+
+	return ""
+}
+
+func constraintValue(key, value string) string {
+	if value == "" {
+		return key
+	}
+
+	return key + "=" + value
+}
+
+func withConstraint(c constraint) RouteOption {
+	return func(r *route) {
+		r.constraints = append(r.constraints, c)
+	}
+}
+
+// sameConstraints checks if both routes require the same constraints, regardless of their order
+func sameConstraints(a, b []constraint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, c := range a {
+		if !hasConstraint(b, c) {
+			return false
+		}
+	}
+	for _, c := range b {
+		if !hasConstraint(a, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasConstraint(constraints []constraint, c constraint) bool {
+	for _, candidate := range constraints {
+		if candidate == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c constraint) matchHTTP(req *http.Request) bool {
+	switch c.kind {
+	case headerConstraint:
+		values, ok := req.Header[c.key]
+		return ok && (c.value == "" || (len(values) > 0 && values[0] == c.value))
+	case queryConstraint:
+		values, ok := req.URL.Query()[c.key]
+		return ok && (c.value == "" || (len(values) > 0 && values[0] == c.value))
+	case schemeConstraint:
+		scheme := req.URL.Scheme
+		if scheme == "" {
+			if req.TLS != nil {
+				scheme = "https"
+			} else {
+				scheme = "http"
+			}
+		}
+		return strings.EqualFold(scheme, c.value)
+	case contentTypeConstraint:
+		return matchMediaType(req.Header.Get("Content-Type"), c.value)
+	}
+
+	return false
+}
+
+func (c constraint) matchFastHTTP(ctx *fasthttp.RequestCtx) bool {
+	switch c.kind {
+	case headerConstraint:
+		value := ctx.Request.Header.Peek(c.key)
+		return value != nil && (c.value == "" || string(value) == c.value)
+	case queryConstraint:
+		args := ctx.QueryArgs()
+		return args.Has(c.key) && (c.value == "" || string(args.Peek(c.key)) == c.value)
+	case schemeConstraint:
+		if ctx.IsTLS() {
+			return c.value == "https"
+		}
+		return strings.EqualFold(string(ctx.URI().Scheme()), c.value)
+	case contentTypeConstraint:
+		return matchMediaType(string(ctx.Request.Header.ContentType()), c.value)
+	}
+
+	return false
+}
+
+// matchMediaType compares media types ignoring parameters such as charset
+func matchMediaType(contentType, mediaType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	return strings.EqualFold(strings.TrimSpace(contentType), mediaType)
+}
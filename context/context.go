@@ -2,17 +2,98 @@ package context
 
 import (
 	"context"
+	"sync"
 )
 
-type key struct{}
+type (
+	paramsKey   struct{}
+	metadataKey struct{}
+	patternKey  struct{}
+	prefixKey   struct{}
+)
+
+// routeContext carries Params, metadata and pattern of the route matched for request,
+// lookup returns the context itself so they are not converted to interface value on every request.
+// Lookup of a value the context does not carry continues with the parent context
+type routeContext struct {
+	context.Context
+	params   Params
+	metadata *RouteMetadata
+	pattern  string
+	// prefix is a pattern of the route request was mounted under
+	prefix string
+}
+
+func (c *routeContext) Value(k interface{}) interface{} {
+	switch k.(type) {
+	case paramsKey:
+		if c.params != nil {
+			return c
+		}
+	case metadataKey:
+		if c.metadata != nil {
+			return c
+		}
+	case patternKey:
+		if c.pattern != "" {
+			return c
+		}
+	case prefixKey:
+		if c.prefix != "" {
+			return c
+		}
+	}
+
+	return c.Context.Value(k)
+}
 
 // WithParams stores params in context
 func WithParams(ctx context.Context, params Params) context.Context {
-	return context.WithValue(ctx, key{}, params)
+	return &routeContext{Context: ctx, params: params}
+}
+
+// WithRoute stores pattern, params and metadata of the route matched for request in context,
+// any of them can be empty
+func WithRoute(ctx context.Context, pattern string, params Params, metadata *RouteMetadata) context.Context {
+	return &routeContext{Context: ctx, pattern: pattern, params: params, metadata: metadata}
+}
+
+var routeContexts = sync.Pool{
+	New: func() interface{} {
+		return new(routeContext)
+	},
+}
+
+// AcquireRoute stores pattern, params and metadata of the route matched for request in context
+// like WithRoute does, reusing context released with ReleaseRoute when possible
+func AcquireRoute(ctx context.Context, pattern string, params Params, metadata *RouteMetadata) context.Context {
+	c := routeContexts.Get().(*routeContext)
+	c.Context = ctx
+	c.pattern = pattern
+	c.params = params
+	c.metadata = metadata
+
+	return c
+}
+
+// ReleaseRoute puts context acquired with AcquireRoute back to the pool, context can not be used after it is released.
+// Router releases request context after handler returns, values read from it remain valid
+func ReleaseRoute(ctx context.Context) {
+	c, ok := ctx.(*routeContext)
+	if !ok {
+		return
+	}
+
+	*c = routeContext{}
+	routeContexts.Put(c)
 }
 
 // Parameters extracts the request Params ctx, if present.
 func Parameters(ctx context.Context) (Params, bool) {
-	params, ok := ctx.Value(key{}).(Params)
-	return params, ok
+	c, ok := ctx.Value(paramsKey{}).(*routeContext)
+	if !ok {
+		return nil, false
+	}
+
+	return c.params, true
 }
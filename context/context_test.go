@@ -1,6 +1,7 @@
 package context
 
 import (
+	"context"
 	"net/http"
 	"testing"
 )
@@ -24,3 +25,64 @@ func TestContext(t *testing.T) {
 		t.Error("Request returned invalid context")
 	}
 }
+
+func TestContextValue(t *testing.T) {
+	type otherKey struct{}
+
+	ctx := context.WithValue(context.Background(), otherKey{}, "value")
+	ctx = WithParams(ctx, Params{{"test", "test"}})
+
+	if v := ctx.Value(otherKey{}); v != "value" {
+		t.Errorf("Expected parent context value, got %v", v)
+	}
+
+	if _, ok := Parameters(context.Background()); ok {
+		t.Error("Expected no params in context without them")
+	}
+}
+
+func TestContextMetadata(t *testing.T) {
+	metadata := &RouteMetadata{Name: "user"}
+
+	ctx := WithRoute(context.Background(), "/users/{id}", Params{{"id", "1"}}, metadata)
+	ctx = WithParams(ctx, Params{{"id", "2"}})
+
+	if m, ok := Metadata(ctx); !ok || m != metadata {
+		t.Errorf("Metadata() = %v, %t, want metadata of parent context", m, ok)
+	}
+	if params, _ := Parameters(ctx); params.Value("id") != "2" {
+		t.Errorf("Parameters() = %v, want the innermost params", params)
+	}
+
+	if _, ok := Metadata(context.Background()); ok {
+		t.Error("Expected no metadata in context without it")
+	}
+}
+
+func TestAcquireRoute(t *testing.T) {
+	metadata := &RouteMetadata{Name: "user"}
+
+	ctx := AcquireRoute(context.Background(), "/users/{id}", Params{{"id", "1"}}, metadata)
+
+	if pattern, ok := Pattern(ctx); !ok || pattern != "/users/{id}" {
+		t.Errorf("Pattern() = %s, %t, want /users/{id}", pattern, ok)
+	}
+	if params, _ := Parameters(ctx); params.Value("id") != "1" {
+		t.Errorf("Parameters() = %v, want id 1", params)
+	}
+	if m, ok := Metadata(ctx); !ok || m != metadata {
+		t.Errorf("Metadata() = %v, %t, want route metadata", m, ok)
+	}
+
+	ReleaseRoute(ctx)
+
+	ctx = AcquireRoute(context.Background(), "", nil, nil)
+	defer ReleaseRoute(ctx)
+
+	if _, ok := Parameters(ctx); ok {
+		t.Error("Expected no params in context acquired without them")
+	}
+	if _, ok := Pattern(ctx); ok {
+		t.Error("Expected no pattern in context acquired without it")
+	}
+}
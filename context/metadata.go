@@ -0,0 +1,53 @@
+package context
+
+import (
+	"context"
+	"time"
+)
+
+// RouteMetadata describes route, it is set with route options at registration time.
+// Router does not enforce Timeout and BodyLimit, they are meant to be applied by middleware
+type RouteMetadata struct {
+	// Name route can be referred to with, e.g. when building its URL
+	Name string
+	// Tags group routes, e.g. for documentation
+	Tags []string
+	// Description of the route
+	Description string
+	// Scopes required to access the route
+	Scopes []string
+	// Timeout for handling the request, zero means no timeout
+	Timeout time.Duration
+	// BodyLimit is the maximum size of request body in bytes, zero means no limit
+	BodyLimit int64
+	// Values hold arbitrary key/value metadata
+	Values map[string]interface{}
+}
+
+// Value provides arbitrary metadata value stored under key
+func (m *RouteMetadata) Value(key string) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	v, ok := m.Values[key]
+
+	return v, ok
+}
+
+// WithMetadata stores route metadata in context
+func WithMetadata(ctx context.Context, metadata *RouteMetadata) context.Context {
+	return &routeContext{Context: ctx, metadata: metadata}
+}
+
+// Metadata extracts metadata of the route matched for request from ctx, if present.
+// It accepts *fasthttp.RequestCtx as well, reading metadata from its "metadata" user value
+func Metadata(ctx context.Context) (*RouteMetadata, bool) {
+	if c, ok := ctx.Value(metadataKey{}).(*routeContext); ok {
+		return c.metadata, true
+	}
+
+	m, ok := ctx.Value("metadata").(*RouteMetadata)
+
+	return m, ok && m != nil
+}
@@ -1,5 +1,13 @@
 package context
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
 type (
 	// Param object to hold request parameter
 	Param struct {
@@ -10,6 +18,79 @@ type (
 	Params []Param
 )
 
+// paramsHolder keeps released Params in pool,
+// holders are reused so putting Params to the pool does not allocate
+type paramsHolder struct {
+	params Params
+}
+
+var (
+	paramsPools [math.MaxUint8 + 1]sync.Pool
+	holders     = sync.Pool{
+		New: func() interface{} {
+			return new(paramsHolder)
+		},
+	}
+)
+
+// AcquireParams provides empty Params of given size,
+// reusing Params released with ReleaseParams when possible
+func AcquireParams(size uint8) Params {
+	if size == 0 {
+		return Params{}
+	}
+
+	if h, ok := paramsPools[size].Get().(*paramsHolder); ok {
+		p := h.params
+		h.params = nil
+		holders.Put(h)
+
+		return p
+	}
+
+	return make(Params, size)
+}
+
+// ReleaseParams puts Params back to the pool, Params can not be used after they are released.
+// Router releases request Params after handler returns, copy them to keep them longer
+func ReleaseParams(p Params) {
+	if len(p) == 0 || len(p) > math.MaxUint8 {
+		return
+	}
+
+	for i := range p {
+		p[i] = Param{}
+	}
+
+	h := holders.Get().(*paramsHolder)
+	h.params = p
+	paramsPools[len(p)].Put(h)
+}
+
+// Copy returns copy of Params which can be used after request Params are released,
+// values are copied as well since they can refer to request buffers reused after handler returns
+func (p Params) Copy() Params {
+	var size int
+	for i := range p {
+		size += len(p[i].Value)
+	}
+
+	// values are copied to a single buffer converted to string once
+	b := make([]byte, 0, size)
+	for i := range p {
+		b = append(b, p[i].Value...)
+	}
+	values := string(b)
+
+	c := make(Params, len(p))
+	for i := range p {
+		c[i].Key = p[i].Key
+		c[i].Value, values = values[:len(p[i].Value)], values[len(p[i].Value):]
+	}
+
+	return c
+}
+
 // Value of the request parameter by name
 func (p Params) Value(key string) string {
 	for i := range p {
@@ -25,3 +106,56 @@ func (p Params) Set(index uint8, key string, value string) {
 	p[index].Value = value
 	p[index].Key = key
 }
+
+// Int of the request parameter by name
+// converted with strconv.Atoi, use with {key:int} parameter type
+func (p Params) Int(key string) (int, error) {
+	value, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(value)
+}
+
+// Int64 of the request parameter by name
+func (p Params) Int64(key string) (int64, error) {
+	value, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Uint64 of the request parameter by name
+// use with {key:uint64} parameter type
+func (p Params) Uint64(key string) (uint64, error) {
+	value, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// Date of the request parameter by name
+// parsed in YYYY-MM-DD format, use with {key:date} parameter type
+func (p Params) Date(key string) (time.Time, error) {
+	value, err := p.lookup(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse("2006-01-02", value)
+}
+
+func (p Params) lookup(key string) (string, error) {
+	for i := range p {
+		if p[i].Key == key {
+			return p[i].Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("context: parameter %q not found", key)
+}
@@ -44,3 +44,66 @@ func TestParamsSet(t *testing.T) {
 		})
 	}
 }
+
+func TestParamsTypedValues(t *testing.T) {
+	params := Params{
+		{"id", "-42"},
+		{"big", "18446744073709551615"},
+		{"day", "2020-02-29"},
+	}
+
+	if v, err := params.Int("id"); err != nil || v != -42 {
+		t.Errorf("Int() = %d, %v", v, err)
+	}
+	if v, err := params.Int64("id"); err != nil || v != -42 {
+		t.Errorf("Int64() = %d, %v", v, err)
+	}
+	if v, err := params.Uint64("big"); err != nil || v != 18446744073709551615 {
+		t.Errorf("Uint64() = %d, %v", v, err)
+	}
+	if v, err := params.Date("day"); err != nil || v.Month() != 2 || v.Day() != 29 {
+		t.Errorf("Date() = %s, %v", v, err)
+	}
+	if _, err := params.Int("missing"); err == nil {
+		t.Error("Int() expected error for missing parameter")
+	}
+	if _, err := params.Uint64("id"); err == nil {
+		t.Error("Uint64() expected error for negative value")
+	}
+}
+
+func TestAcquireParams(t *testing.T) {
+	p := AcquireParams(2)
+	if len(p) != 2 {
+		t.Fatalf("Expected params of size 2, got %d", len(p))
+	}
+
+	p.Set(0, "id", "1")
+	p.Set(1, "name", "x")
+	ReleaseParams(p)
+
+	for i := 0; i < 10; i++ {
+		p := AcquireParams(2)
+		for _, param := range p {
+			if param != (Param{}) {
+				t.Errorf("Expected acquired params to be empty, got %v", p)
+			}
+		}
+	}
+
+	if p := AcquireParams(0); p == nil || len(p) != 0 {
+		t.Errorf("Expected empty non nil params, got %#v", p)
+	}
+}
+
+func TestParamsCopy(t *testing.T) {
+	p := AcquireParams(1)
+	p.Set(0, "id", "1")
+
+	c := p.Copy()
+	ReleaseParams(p)
+
+	if c.Value("id") != "1" {
+		t.Errorf("Expected copy to keep released params values, got %v", c)
+	}
+}
@@ -0,0 +1,104 @@
+package context
+
+import (
+	"context"
+	"sync"
+)
+
+type holderKey struct{}
+
+// patternHolder holds pattern of the route matched for request once it is routed,
+// so it can be read by middleware which passed the request on before routing
+type patternHolder struct {
+	context.Context
+	pattern string
+}
+
+func (c *patternHolder) Value(k interface{}) interface{} {
+	switch k.(type) {
+	case holderKey:
+		return c
+	case patternKey:
+		if c.pattern != "" {
+			return c
+		}
+	}
+
+	return c.Context.Value(k)
+}
+
+var patternHolders = sync.Pool{
+	New: func() interface{} {
+		return new(patternHolder)
+	},
+}
+
+// AcquirePatternHolder provides context holding pattern set later with SetPattern,
+// reusing context released with ReleasePatternHolder when possible.
+// Reports false and returns ctx as it is when ctx already holds pattern
+func AcquirePatternHolder(ctx context.Context) (context.Context, bool) {
+	if _, ok := ctx.Value(holderKey{}).(*patternHolder); ok {
+		return ctx, false
+	}
+
+	c := patternHolders.Get().(*patternHolder)
+	c.Context = ctx
+
+	return c, true
+}
+
+// ReleasePatternHolder puts context acquired with AcquirePatternHolder back to the pool,
+// context can not be used after it is released
+func ReleasePatternHolder(ctx context.Context) {
+	c, ok := ctx.(*patternHolder)
+	if !ok {
+		return
+	}
+
+	*c = patternHolder{}
+	patternHolders.Put(c)
+}
+
+// SetPattern sets pattern held by ctx, reports false if ctx does not hold pattern
+func SetPattern(ctx context.Context, pattern string) bool {
+	c, ok := ctx.Value(holderKey{}).(*patternHolder)
+	if !ok {
+		return false
+	}
+
+	c.pattern = pattern
+
+	return true
+}
+
+// WithPrefix stores pattern of the route request is mounted under in context,
+// patterns matched by mounted router are prefixed with it
+func WithPrefix(ctx context.Context, prefix string) context.Context {
+	return &routeContext{Context: ctx, prefix: prefix}
+}
+
+// Prefix extracts pattern of the route request is mounted under from ctx, if present
+func Prefix(ctx context.Context) (string, bool) {
+	c, ok := ctx.Value(prefixKey{}).(*routeContext)
+	if !ok {
+		return "", false
+	}
+
+	return c.prefix, true
+}
+
+// Pattern extracts pattern of the route matched for request from ctx, if present.
+// Pattern of route of mounted router includes the mount pattern.
+// It accepts *fasthttp.RequestCtx as well, reading pattern from its "pattern" user value
+func Pattern(ctx context.Context) (string, bool) {
+	switch c := ctx.Value(patternKey{}).(type) {
+	case *routeContext:
+		return c.pattern, true
+	case *patternHolder:
+		return c.pattern, true
+	}
+
+	pattern, ok := ctx.Value("pattern").(string)
+
+	return pattern, ok && pattern != ""
+}
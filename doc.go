@@ -4,8 +4,10 @@ Package gorouter provide request router with middleware
 Router
 
 The router determines how to handle http request.
-GoRouter uses a routing tree. Once one branch of the tree matches, only routes inside that branch are considered,
-not any routes after that branch. When instantiating router, the root node of tree is created.
+GoRouter uses a routing tree. Branches of the tree are tried in priority order: static, regexp and named.
+When a branch matches beginning of the path but has no route for the rest of it,
+routing falls back to the next sibling branch. Middleware is collected from the branch the route was found in.
+When instantiating router, the root node of tree is created.
 
 Route types
 
@@ -13,7 +15,19 @@ Route types
 
 - Named `/{name}` (will match requests matching given route scheme)
 
-- Regexp `/{name:[a-z]+}` (will match requests matching given route scheme and its regexp)
+- Regexp `/{name:[a-z]+}` (will match requests matching given route scheme and its regexp, regexp has to match the whole path part unless prefixed with `~`)
+
+- Catch-all `/{name...}` or `/{name*}` (will match the rest of the request path including slashes, has to be the last path part)
+
+- Embedded `/{year}-{month}.{format}` (will match path part mixing static text and parameters,
+parameters have to be separated by static text and take the shortest value followed by it)
+
+Hosts
+
+Pattern not starting with slash is prefixed with host pattern, e.g. `{tenant}.example.com/users`.
+Host labels can be static, named or regexp, port is matched only if pattern contains one.
+Each host has its own routing tree, routes registered without host serve requests
+which host did not match any host pattern.
 
 Wildcards
 
@@ -325,3 +325,16 @@ func ExampleRouter_compile() {
 	// Output:
 	// Health OK!
 }
+
+func ExampleRouter_url() {
+	show := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	router := gorouter.New()
+	router.GET("/blog/{postsId}", http.HandlerFunc(show), gorouter.WithName("post.show"))
+
+	url, _ := router.URL("post.show", "postsId", "42")
+	fmt.Println(url)
+
+	// Output:
+	// /blog/42
+}
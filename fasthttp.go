@@ -1,12 +1,18 @@
 package gorouter
 
 import (
+	"fmt"
+	"math"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 
 	pathutils "github.com/vardius/gorouter/v4/path"
 
 	"github.com/valyala/fasthttp"
 
+	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
 	"github.com/vardius/gorouter/v4/mux"
 )
@@ -19,106 +25,299 @@ func NewFastHTTPRouter(fs ...FastHTTPMiddlewareFunc) FastHTTPRouter {
 		tree:              mux.NewTree(),
 		globalMiddleware:  globalMiddleware,
 		middlewareCounter: uint(len(globalMiddleware)),
+		names:             make(namedRoutes),
+		orphans:           make(orphanIndex),
 	}
 
 	r.handler = globalMiddleware.Compose(fasthttp.RequestHandler(r.serveHTTP)).(fasthttp.RequestHandler)
+	r.publish()
 
 	return r
 }
 
 type fastHTTPRouter struct {
+	// mu guards Tree and registration state,
+	// requests are matched using published snapshot without locking.
+	// Snapshot shares the Tree, changed Nodes are copied instead of being modified
+	mu                sync.RWMutex
 	tree              mux.Tree
+	orphans           orphanIndex
+	compiled          bool
+	current           atomic.Value // *snapshot
 	globalMiddleware  middleware.Collection
 	fileServer        fasthttp.RequestHandler
 	notFound          fasthttp.RequestHandler
 	notAllowed        fasthttp.RequestHandler
 	handler           fasthttp.RequestHandler
 	middlewareCounter uint
+	names             namedRoutes
+	mounts            []mountedRouter
+	hostRouting       bool
+	pathPolicy        PathPolicy
 }
 
 func (r *fastHTTPRouter) PrettyPrint() string {
-	return r.tree.PrettyPrint()
+	return r.routing().tree.PrettyPrint()
 }
 
-func (r *fastHTTPRouter) POST(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPost, p, f)
+func (r *fastHTTPRouter) POST(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodPost, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) GET(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodGet, p, f)
+func (r *fastHTTPRouter) GET(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodGet, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) PUT(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPut, p, f)
+func (r *fastHTTPRouter) PUT(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodPut, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) DELETE(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodDelete, p, f)
+func (r *fastHTTPRouter) DELETE(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodDelete, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) PATCH(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPatch, p, f)
+func (r *fastHTTPRouter) PATCH(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodPatch, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) OPTIONS(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodOptions, p, f)
+func (r *fastHTTPRouter) OPTIONS(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodOptions, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) HEAD(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodHead, p, f)
+func (r *fastHTTPRouter) HEAD(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodHead, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) CONNECT(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodConnect, p, f)
+func (r *fastHTTPRouter) CONNECT(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodConnect, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) TRACE(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodTrace, p, f)
+func (r *fastHTTPRouter) TRACE(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	r.Handle(fasthttp.MethodTrace, p, f, opts...)
 }
 
-func (r *fastHTTPRouter) USE(method, path string, fs ...FastHTTPMiddlewareFunc) {
+func (r *fastHTTPRouter) USE(method, pattern string, fs ...FastHTTPMiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	m := transformFastHTTPMiddlewareFunc(fs...)
 	for i, mf := range m {
 		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
 	}
 
-	r.tree = r.tree.WithMiddleware(method+path, m, 0)
+	host, path := r.splitHostPattern(pattern)
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		return t.WithMiddleware(method+path, m, maxParamsSize)
+	})
+	r.tree = r.pathPolicy.applyPath(r.tree, host, method+path)
+	change.precompose(r.tree, r.orphans, true)
 	r.middlewareCounter += uint(len(m))
+	r.publish()
+}
+
+func (r *fastHTTPRouter) Handle(method, pattern string, h fasthttp.RequestHandler, opts ...RouteOption) {
+	if err := r.Register(method, pattern, h, opts...); err != nil {
+		panic(err)
+	}
+}
+
+func (r *fastHTTPRouter) Register(method, pattern string, h fasthttp.RequestHandler, opts ...RouteOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route := newRoute(h, opts...)
+	host, path := splitHostPattern(pattern)
+	route.pattern = path
+
+	if err := checkRoute(r.tree, host, method+path, route); err != nil {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, err)
+	}
+	if err := r.names.check(route.name(), path); err != nil {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, err)
+	}
+	if len(route.httpMiddleware) > 0 {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, ErrMiddlewareType)
+	}
+
+	route.middleware = transformFastHTTPMiddlewareFunc(route.fastHTTPMiddleware...)
+	for i, mf := range route.middleware {
+		route.middleware[i] = middleware.WithPriority(mf, r.middlewareCounter)
+	}
+	r.middlewareCounter += uint(len(route.middleware))
+
+	if host != "" {
+		r.hostRouting = true
+	}
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		return t.WithRoute(method+path, route, maxParamsSize)
+	})
+	r.tree = r.pathPolicy.applyPath(r.tree, host, method+path)
+	change.precompose(r.tree, r.orphans, false)
+
+	if route.name() != "" {
+		r.names.add(route.name(), path)
+	}
+
+	r.publish()
+
+	return nil
+}
+
+func (r *fastHTTPRouter) Remove(method, pattern string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host, path := splitHostPattern(pattern)
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	var removed mux.Route
+	r.tree, removed = withoutRoute(r.tree, host, method+path)
+	if removed == nil {
+		return false
+	}
+
+	change.precompose(r.tree, r.orphans, false)
+
+	if route, ok := removed.(*route); ok && route.named() {
+		r.names.prune(r.tree)
+	}
+	r.publish()
+
+	return true
 }
 
-func (r *fastHTTPRouter) Handle(method, path string, h fasthttp.RequestHandler) {
-	route := newRoute(h)
+func (r *fastHTTPRouter) Mount(pattern string, h fasthttp.RequestHandler) {
+	r.mount(pattern, h, nil, nil)
+}
 
-	r.tree = r.tree.WithRoute(method+path, route, 0)
+func (r *fastHTTPRouter) MountRouter(pattern string, sub FastHTTPRouter) {
+	r.mount(pattern, sub.HandleFastHTTP, sub, nil)
 }
 
-func (r *fastHTTPRouter) Mount(path string, h fasthttp.RequestHandler) {
+// mount mounts handler wrapped with middleware of the groups it is mounted within,
+// sub is a router handler belongs to, nil for handler of other kind
+func (r *fastHTTPRouter) mount(pattern string, h fasthttp.RequestHandler, sub FastHTTPRouter, fs []FastHTTPMiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host, path := r.splitHostPattern(pattern)
 	pathRewrite := fasthttp.NewPathSlashesStripper(strings.Count(path, "/"))
 	route := newRoute(fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
 		ctx.URI().SetPathBytes(pathRewrite(ctx))
+		// patterns matched by mounted router are prefixed with pattern of the mount route
+		ctx.SetUserValue("mount", ctx.UserValue("pattern"))
 
 		h(ctx)
 	}))
+	route.middleware = transformFastHTTPMiddlewareFunc(fs...)
+	route.subrouter = h
+	if sub != nil {
+		route.router = sub
+	}
+	route.mount = path
+
+	var changes []treeChange
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		for _, method := range []string{
+			fasthttp.MethodGet,
+			fasthttp.MethodHead,
+			fasthttp.MethodPost,
+			fasthttp.MethodPut,
+			fasthttp.MethodPatch,
+			fasthttp.MethodDelete,
+			fasthttp.MethodConnect,
+			fasthttp.MethodOptions,
+			fasthttp.MethodTrace,
+		} {
+			changes = append(changes, newTreeChange(r.tree, host, method+path))
+			t = t.WithSubrouter(method+path, route, maxParamsSize)
+		}
+
+		return t
+	})
+	for _, change := range changes {
+		r.tree = r.pathPolicy.applyPath(r.tree, host, change.path)
+		change.precompose(r.tree, r.orphans, false)
+	}
 
-	for _, method := range []string{
-		fasthttp.MethodGet,
-		fasthttp.MethodHead,
-		fasthttp.MethodPost,
-		fasthttp.MethodPut,
-		fasthttp.MethodPatch,
-		fasthttp.MethodDelete,
-		fasthttp.MethodConnect,
-		fasthttp.MethodOptions,
-		fasthttp.MethodTrace,
-	} {
-		r.tree = r.tree.WithSubrouter(method+path, route, 0)
+	if sub != nil {
+		r.mounts = append(r.mounts, mountedRouter{
+			template: newURLTemplate(path),
+			handler:  sub,
+		})
 	}
+	r.publish()
+}
+
+func (r *fastHTTPRouter) Group(prefix string, fn func(FastHTTPRouter), fs ...FastHTTPMiddlewareFunc) {
+	fn(&fastHTTPGroup{FastHTTPRouter: r, prefix: prefix, middleware: fs})
+}
+
+func (r *fastHTTPRouter) URL(name string, params ...string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return buildURL(r.names, r.mounts, name, params...)
+}
+
+func (r *fastHTTPRouter) Routes() []RouteInfo {
+	var routes []RouteInfo
+
+	_ = r.Walk(func(route RouteInfo) error {
+		routes = append(routes, route)
+		return nil
+	})
+
+	return routes
+}
+
+func (r *fastHTTPRouter) Walk(fn WalkFunc) error {
+	return walk(r.routing().tree, fn)
+}
+
+func (r *fastHTTPRouter) Lint() []mux.Issue {
+	return r.routing().tree.Lint()
+}
+
+func (r *fastHTTPRouter) Validate() error {
+	return validate(r.routing().tree)
 }
 
 func (r *fastHTTPRouter) Compile() {
-	for i, methodNode := range r.tree {
-		r.tree[i].WithChildren(methodNode.Tree().Compile())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.compiled = true
+	r.publish()
+}
+
+// routing provides published snapshot of routing state
+func (r *fastHTTPRouter) routing() *snapshot {
+	return r.current.Load().(*snapshot)
+}
+
+// publish builds snapshot of routing state and publishes it for requests,
+// so requests never wait for routing changes. Has to be called with mu locked
+func (r *fastHTTPRouter) publish() {
+	r.current.Store(newSnapshot(r.tree, r.compiled, r.hostRouting, r.pathPolicy))
+}
+
+// splitHostPattern splits pattern into host and path, enables host routing when needed
+func (r *fastHTTPRouter) splitHostPattern(pattern string) (host, path string) {
+	host, path = splitHostPattern(pattern)
+	if host != "" {
+		r.hostRouting = true
 	}
+
+	return host, path
 }
 
 func (r *fastHTTPRouter) NotFound(notFound fasthttp.RequestHandler) {
@@ -129,6 +328,16 @@ func (r *fastHTTPRouter) NotAllowed(notAllowed fasthttp.RequestHandler) {
 	r.notAllowed = notAllowed
 }
 
+func (r *fastHTTPRouter) PathPolicy(policy PathPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// published Tree is shared with the router one, so policy is applied to its copy
+	r.tree = policy.reapply(r.tree.Clone(), r.pathPolicy)
+	r.pathPolicy = policy
+	r.publish()
+}
+
 func (r *fastHTTPRouter) ServeFiles(root string, stripSlashes int) {
 	if root == "" {
 		panic("gorouter.ServeFiles: empty root!")
@@ -142,46 +351,113 @@ func (r *fastHTTPRouter) HandleFastHTTP(ctx *fasthttp.RequestCtx) {
 }
 
 func (r *fastHTTPRouter) serveHTTP(ctx *fasthttp.RequestCtx) {
-	method := string(ctx.Method())
-	path := string(ctx.Path())
+	method := methodName(ctx.Method())
+	// path is matched as bytes, converted to string only when needed
+	path := ctx.Path()
+
+	prefix := mountPrefix(ctx)
+
+	s := r.routing()
+	policy := s.policy
+
+	if policy.CleanPath {
+		clean, ok := policy.cleanPath(string(path))
+		// fasthttp normalizes path by default, original path tells if request path was canonical
+		original := string(ctx.URI().PathOriginal())
 
-	if root := r.tree.Find(method); root != nil {
+		if ok || pathutils.Clean(original) != original {
+			if policy.redirects(method) {
+				r.redirect(ctx, clean, policy.RedirectCode)
+				return
+			}
+
+			ctx.URI().SetPath(clean)
+			path = []byte(clean)
+		}
+	}
+
+	tree := s.tree
+	var hostParams context.Params
+	if s.hostRouting {
+		tree, hostParams = s.tree.MatchHost(string(ctx.Host()))
+		defer context.ReleaseParams(hostParams)
+	}
+
+	if root := tree.Find(method); root != nil {
 		var h fasthttp.RequestHandler
 
-		if path == "/" {
-			if root.Route() != nil && root.Route().Handler() != nil {
-				if r.middlewareCounter > 0 {
-					computedHandler := root.Middleware().Sort().Compose(root.Route().Handler())
+		if len(path) == 1 && path[0] == '/' {
+			if rootRoute, unsupported := selectFastHTTPRoute(root.Route(), ctx); rootRoute != nil {
+				h = rootRoute.chain.(fasthttp.RequestHandler)
 
-					h = computedHandler.(fasthttp.RequestHandler)
-				} else {
-					h = root.Route().Handler().(fasthttp.RequestHandler)
+				if len(hostParams) > 0 {
+					u := acquireUserParams(hostParams)
+					ctx.SetUserValue("params", u.value)
+					defer releaseUserParams(ctx, u)
+				}
+				setPattern(ctx, rootRoute, prefix)
+				if rootRoute.meta != nil {
+					ctx.SetUserValue("metadata", rootRoute.meta)
 				}
 
 				h(ctx)
 				return
+			} else if unsupported {
+				r.serveUnsupportedMediaType(ctx)
+				return
 			}
 		} else {
-			path = pathutils.TrimSlash(path)
-
-			if route, params := root.Tree().MatchRoute(path); route != nil {
-				if r.middlewareCounter > 0 {
-					var allMiddleware middleware.Collection
-					if treeMiddleware := root.Tree().MatchMiddleware(path); len(treeMiddleware) > 0 {
-						allMiddleware = root.Middleware().Merge(treeMiddleware).Sort()
-					} else {
-						allMiddleware = root.Middleware().Sort()
+			// parameter values refer to the path copy, request buffers can be modified by handler
+			buf := acquirePathBuffer(path)
+			defer releasePathBuffer(buf)
+
+			pathString := b2s(buf.b)
+			matchedRoute, params := s.matchRoute(root, pathutils.TrimSlash(pathString))
+			defer context.ReleaseParams(params)
+			route, unsupported := selectFastHTTPRoute(matchedRoute, ctx)
+			if unsupported {
+				r.serveUnsupportedMediaType(ctx)
+				return
+			}
+
+			if route != nil {
+				if policy.TrailingSlash == TrailingSlashStrict {
+					if canonical, ok := policy.trailingSlashPath(route, pathString); ok {
+						if policy.redirects(method) {
+							r.redirect(ctx, canonical, policy.RedirectCode)
+						} else {
+							r.serveNotFound(ctx)
+						}
+						return
 					}
+				}
 
-					computedHandler := allMiddleware.Compose(route.Handler())
+				if policy.IgnoreCase && policy.redirects(method) {
+					if canonical, ok := policy.casePath(route, pathString, params); ok {
+						r.redirect(ctx, canonical, policy.RedirectCode)
+						return
+					}
+				}
+
+				if route.orphanMiddleware {
+					computedHandler := routeMiddleware(root, pathutils.TrimSlash(pathString)).Compose(route.routeHandler())
 
 					h = computedHandler.(fasthttp.RequestHandler)
 				} else {
-					h = route.Handler().(fasthttp.RequestHandler)
+					h = route.chain.(fasthttp.RequestHandler)
 				}
 
+				// host parameters take the first indexes of params
+				copy(params, hostParams)
+
 				if len(params) > 0 {
-					ctx.SetUserValue("params", params)
+					u := acquireUserParams(params)
+					ctx.SetUserValue("params", u.value)
+					defer releaseUserParams(ctx, u)
+				}
+				setPattern(ctx, route, prefix)
+				if route.meta != nil {
+					ctx.SetUserValue("metadata", route.meta)
 				}
 
 				h(ctx)
@@ -190,8 +466,6 @@ func (r *fastHTTPRouter) serveHTTP(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
-	path = pathutils.TrimSlash(path)
-
 	// Handle file serve
 	if method == fasthttp.MethodGet && r.fileServer != nil {
 		r.fileServer(ctx)
@@ -199,7 +473,7 @@ func (r *fastHTTPRouter) serveHTTP(ctx *fasthttp.RequestCtx) {
 	}
 
 	// Handle OPTIONS
-	if allow := allowed(r.tree, method, path); len(allow) > 0 {
+	if allow := allowed(tree, method, pathutils.TrimSlash(string(path))); len(allow) > 0 {
 		ctx.Response.Header.Set("Allow", allow)
 
 		if method == fasthttp.MethodOptions {
@@ -215,6 +489,17 @@ func (r *fastHTTPRouter) serveHTTP(ctx *fasthttp.RequestCtx) {
 	r.serveNotFound(ctx)
 }
 
+// redirect redirects request to given path keeping query string
+func (r *fastHTTPRouter) redirect(ctx *fasthttp.RequestCtx, path string, code int) {
+	uri := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(uri)
+
+	ctx.URI().CopyTo(uri)
+	uri.SetPath(path)
+
+	ctx.Redirect(string(uri.RequestURI()), code)
+}
+
 func (r *fastHTTPRouter) serveNotFound(ctx *fasthttp.RequestCtx) {
 	if r.notFound != nil {
 		r.notFound(ctx)
@@ -223,6 +508,10 @@ func (r *fastHTTPRouter) serveNotFound(ctx *fasthttp.RequestCtx) {
 	}
 }
 
+func (r *fastHTTPRouter) serveUnsupportedMediaType(ctx *fasthttp.RequestCtx) {
+	ctx.Error(fasthttp.StatusMessage(fasthttp.StatusUnsupportedMediaType), fasthttp.StatusUnsupportedMediaType)
+}
+
 func (r *fastHTTPRouter) serveNotAllowed(ctx *fasthttp.RequestCtx) {
 	if r.notAllowed != nil {
 		r.notAllowed(ctx)
@@ -231,6 +520,15 @@ func (r *fastHTTPRouter) serveNotAllowed(ctx *fasthttp.RequestCtx) {
 	}
 }
 
+// selectFastHTTPRoute selects route or one of its alternatives which constraints request satisfies
+func selectFastHTTPRoute(matched mux.Route, ctx *fasthttp.RequestCtx) (*route, bool) {
+	if r, ok := matched.(*route); ok {
+		return r.matchFastHTTP(ctx)
+	}
+
+	return nil, false
+}
+
 func transformFastHTTPMiddlewareFunc(fs ...FastHTTPMiddlewareFunc) middleware.Collection {
 	m := make(middleware.Collection, len(fs))
 
@@ -244,3 +542,122 @@ func transformFastHTTPMiddlewareFunc(fs ...FastHTTPMiddlewareFunc) middleware.Co
 
 	return m
 }
+
+// userParams holds Params stored as request user value,
+// value is boxed once so setting it for request does not allocate
+type userParams struct {
+	params context.Params
+	value  interface{}
+}
+
+var userParamsPools [math.MaxUint8 + 1]sync.Pool
+
+func acquireUserParams(p context.Params) *userParams {
+	u, ok := userParamsPools[len(p)].Get().(*userParams)
+	if !ok {
+		u = &userParams{params: make(context.Params, len(p))}
+		u.value = u.params
+	}
+
+	copy(u.params, p)
+
+	return u
+}
+
+// releaseUserParams removes Params from request user values before reusing them
+func releaseUserParams(ctx *fasthttp.RequestCtx, u *userParams) {
+	ctx.SetUserValue("params", nil)
+
+	for i := range u.params {
+		u.params[i] = context.Param{}
+	}
+
+	userParamsPools[len(u.params)].Put(u)
+}
+
+// pathBuffer holds copy of request path which parameter values refer to until handler returns
+type pathBuffer struct {
+	b []byte
+}
+
+var pathBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(pathBuffer)
+	},
+}
+
+func acquirePathBuffer(path []byte) *pathBuffer {
+	buf := pathBuffers.Get().(*pathBuffer)
+	buf.b = append(buf.b[:0], path...)
+
+	return buf
+}
+
+func releasePathBuffer(buf *pathBuffer) {
+	pathBuffers.Put(buf)
+}
+
+// b2s converts bytes to string without copying them, string is valid as long as bytes are not modified
+func b2s(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// setPattern stores pattern of route matched for request in its user values
+func setPattern(ctx *fasthttp.RequestCtx, r *route, prefix string) {
+	if prefix == "" {
+		ctx.SetUserValue("pattern", r.patternValue)
+		return
+	}
+
+	ctx.SetUserValue("pattern", r.requestPattern(prefix))
+}
+
+// mountPrefix provides pattern of the mount route request was passed on by,
+// it is removed from user values so it does not prefix patterns of requests routed later
+func mountPrefix(ctx *fasthttp.RequestCtx) string {
+	prefix, ok := ctx.UserValue("mount").(string)
+	if ok {
+		ctx.SetUserValue("mount", nil)
+	}
+
+	return prefix
+}
+
+// methodName provides request method, standard methods are provided without copying it
+func methodName(method []byte) string {
+	switch string(method) {
+	case fasthttp.MethodGet:
+		return fasthttp.MethodGet
+	case fasthttp.MethodHead:
+		return fasthttp.MethodHead
+	case fasthttp.MethodPost:
+		return fasthttp.MethodPost
+	case fasthttp.MethodPut:
+		return fasthttp.MethodPut
+	case fasthttp.MethodPatch:
+		return fasthttp.MethodPatch
+	case fasthttp.MethodDelete:
+		return fasthttp.MethodDelete
+	case fasthttp.MethodConnect:
+		return fasthttp.MethodConnect
+	case fasthttp.MethodOptions:
+		return fasthttp.MethodOptions
+	case fasthttp.MethodTrace:
+		return fasthttp.MethodTrace
+	}
+
+	return string(method)
+}
+
+// trimSlash trims '/' of URL path like pathutils.TrimSlash does
+func trimSlash(path []byte) []byte {
+	if len(path) > 0 && path[0] == '/' {
+		path = path[1:]
+	}
+
+	if len(path) > 0 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+
+	return path
+}
@@ -1,15 +1,18 @@
 package gorouter
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/valyala/fasthttp"
 
 	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/mux"
 )
 
 func buildFastHTTPRequestContext(method, path string) *fasthttp.RequestCtx {
@@ -83,6 +86,7 @@ func TestFastHTTPMethods(t *testing.T) {
 		fasthttp.MethodTrace,
 		fasthttp.MethodOptions,
 	} {
+		method := method
 		t.Run(method, func(t *testing.T) {
 			t.Parallel()
 
@@ -666,3 +670,810 @@ func TestFastHTTPMountSubRouter_third(t *testing.T) {
 		t.Errorf("subrouter route did not match: %s", ctx.Response.Body())
 	}
 }
+
+func TestFastHTTPBacktrackingSiblingBranches(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/users/new", func(ctx *fasthttp.RequestCtx) { _, _ = fmt.Fprint(ctx, "GET[/users/new]") })
+	router.GET("/{org}/repos", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprintf(ctx, "GET[/%s/repos]", params.Value("org"))
+	})
+	router.USE(http.MethodGet, "/users", mockFastHTTPMiddleware("USE[/users]"))
+
+	for _, compile := range []bool{false, true} {
+		if compile {
+			router.Compile()
+		}
+
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/repos")
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != "GET[/users/repos]" {
+			t.Errorf("route did not fall back to sibling branch (compiled: %t): %s", compile, ctx.Response.Body())
+		}
+
+		ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/new")
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != "USE[/users]GET[/users/new]" {
+			t.Errorf("static route did not match (compiled: %t): %s", compile, ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPCatchAllParam(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/files/{filepath*}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprint(ctx, params.Value("filepath"))
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/files/css/site/main.css")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "css/site/main.css" {
+		t.Errorf("Wrong params value. Expected 'css/site/main.css', actual '%s'", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodPost, "/files/css/site/main.css")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, actual %d", fasthttp.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	}
+}
+
+func TestFastHTTPEmbeddedParams(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/reports/{year}-{month}.{format}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprintf(ctx, "%s|%s|%s", params.Value("year"), params.Value("month"), params.Value("format"))
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/reports/2020-01.csv")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "2020|01|csv" {
+		t.Errorf("Wrong params value. Expected '2020|01|csv', actual '%s'", ctx.Response.Body())
+	}
+}
+
+func TestFastHTTPURL(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/blog/{postsId}", handler.HandleFastHTTP, WithName("post.show"))
+
+	got, err := router.URL("post.show", "postsId", "4 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "/blog/4%202" {
+		t.Errorf("URL(post.show) = %s, want /blog/4%%202", got)
+	}
+
+	subRouter := NewFastHTTPRouter()
+	subRouter.GET("/", handler.HandleFastHTTP, WithName("user.index"))
+	subRouter.GET("/{id:[0-9]+}", handler.HandleFastHTTP, WithName("user.show"))
+
+	router.MountRouter("/{org}/users", subRouter)
+
+	tests := []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{"user.index", []string{"org", "acme"}, "/acme/users"},
+		{"user.show", []string{"org", "acme", "id", "7"}, "/acme/users/7"},
+	}
+	for _, tt := range tests {
+		got, err := router.URL(tt.name, tt.params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("URL(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := router.URL("user.show", "org", "acme", "id", "x"); err == nil {
+		t.Error("URL should fail for parameter not matching regexp")
+	}
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/acme/users/7")
+	router.HandleFastHTTP(ctx)
+
+	if !handler.served {
+		t.Error("Mounted router should serve the request")
+	}
+}
+
+func TestFastHTTPHostRouting(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, "default")
+	})
+	router.GET("{tenant}.example.com/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprintf(ctx, "%s:%s", params.Value("tenant"), params.Value("id"))
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+	ctx.URI().SetHost("acme.example.com")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "acme:1" {
+		t.Errorf("host route did not match: %s", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+	ctx.URI().SetHost("localhost")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "default" {
+		t.Errorf("default route did not match: %s", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodPost, "/users/1")
+	ctx.URI().SetHost("acme.example.com")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, actual %d", fasthttp.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	}
+}
+
+func TestFastHTTPRouteConstraints(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.POST("/users", func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, "json")
+	}, WithContentType("application/json"))
+	router.POST("/users", func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, "default")
+	})
+	router.GET("/users", func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, "csv")
+	}, WithQuery("format", "csv"))
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodPost, "/users")
+	ctx.Request.Header.SetContentType("application/json; charset=utf-8")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "json" {
+		t.Errorf("json route did not match: %s", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodPost, "/users")
+	ctx.Request.Header.SetContentType("text/plain")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "default" {
+		t.Errorf("fallback route did not match: %s", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/users")
+	ctx.URI().SetQueryString("format=csv")
+	router.HandleFastHTTP(ctx)
+
+	if string(ctx.Response.Body()) != "csv" {
+		t.Errorf("csv route did not match: %s", ctx.Response.Body())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/users")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, actual %d", fasthttp.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	}
+}
+
+func TestFastHTTPPathPolicy(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	tests := []struct {
+		name     string
+		policy   PathPolicy
+		method   string
+		uri      string
+		code     int
+		location string
+		body     string
+	}{
+		{"lenient", PathPolicy{}, fasthttp.MethodGet, "/users/", fasthttp.StatusOK, "", "/users/"},
+		{"clean rewrite", PathPolicy{CleanPath: true}, fasthttp.MethodGet, "/posts//../users", fasthttp.StatusOK, "", "/users"},
+		{"clean redirect", PathPolicy{CleanPath: true, RedirectCode: fasthttp.StatusMovedPermanently}, fasthttp.MethodGet, "/users/./?page=2", fasthttp.StatusMovedPermanently, "/users/?page=2", ""},
+		{"clean post rewrite", PathPolicy{CleanPath: true, RedirectCode: fasthttp.StatusMovedPermanently}, fasthttp.MethodPost, "//users", fasthttp.StatusOK, "", "/users"},
+		{"strict", PathPolicy{TrailingSlash: TrailingSlashStrict}, fasthttp.MethodGet, "/users/", fasthttp.StatusNotFound, "", ""},
+		{"strict redirect remove", PathPolicy{TrailingSlash: TrailingSlashStrict, RedirectCode: fasthttp.StatusPermanentRedirect}, fasthttp.MethodGet, "/users/", fasthttp.StatusPermanentRedirect, "/users", ""},
+		{"strict redirect add", PathPolicy{TrailingSlash: TrailingSlashStrict, RedirectCode: fasthttp.StatusPermanentRedirect}, fasthttp.MethodGet, "/dirs", fasthttp.StatusPermanentRedirect, "/dirs/", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewFastHTTPRouter()
+			router.PathPolicy(tt.policy)
+			router.GET("/users", handler)
+			router.POST("/users", handler)
+			router.GET("/dirs/", handler)
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI(tt.uri)
+			ctx.Request.Header.SetHost("example.com")
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected status %d, actual %d", tt.code, ctx.Response.StatusCode())
+			}
+			if tt.location != "" && !strings.HasSuffix(string(ctx.Response.Header.Peek("Location")), tt.location) {
+				t.Errorf("expected location %q, actual %q", tt.location, ctx.Response.Header.Peek("Location"))
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected body %q, actual %q", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+}
+
+func TestFastHTTPPathPolicyIgnoreCase(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter()
+	router.GET("/blog/posts/{id}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprint(ctx, params.Value("id"))
+	})
+	router.PathPolicy(PathPolicy{IgnoreCase: true})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/Blog/POSTS/AbC")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK || string(ctx.Response.Body()) != "AbC" {
+		t.Errorf("expected status 200 with parameter in original case, actual %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	router.PathPolicy(PathPolicy{IgnoreCase: true, RedirectCode: fasthttp.StatusMovedPermanently})
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/Blog/POSTS/AbC")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMovedPermanently || !strings.HasSuffix(string(ctx.Response.Header.Peek("Location")), "/blog/posts/AbC") {
+		t.Errorf("expected redirect to registered case, actual %d %q", ctx.Response.StatusCode(), ctx.Response.Header.Peek("Location"))
+	}
+
+	router.PathPolicy(PathPolicy{})
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/Blog/POSTS/AbC")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("expected status 404 once case is no longer ignored, actual %d", ctx.Response.StatusCode())
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/blog/posts/AbC")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("expected status 200 for registered case, actual %d", ctx.Response.StatusCode())
+	}
+}
+
+func TestFastHTTPRoutes(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := NewFastHTTPRouter()
+	router.USE(fasthttp.MethodGet, "/users", mockFastHTTPMiddleware("m1"))
+	router.GET("/users/{id:int}", handler.HandleFastHTTP, WithName("user"))
+	router.Mount("/static", handler.HandleFastHTTP)
+
+	sub := NewFastHTTPRouter()
+	sub.GET("/{id:int}", handler.HandleFastHTTP, WithName("item"))
+	router.MountRouter("/items", sub)
+
+	routes := router.Routes()
+	if len(routes) != 11 {
+		t.Fatalf("Expected route, mounted router route and mounted handler for every method, got %d routes", len(routes))
+	}
+
+	var mounted []string
+	var static *RouteInfo
+	for i, route := range routes {
+		if route.Name == "item" {
+			mounted = append(mounted, fmt.Sprintf("%s %s %v %t", route.Method, route.Pattern, route.Params, route.Mounted))
+		}
+		if static == nil && route.Pattern == "/static" {
+			static = &routes[i]
+		}
+	}
+	if want := []string{"GET /items/{id:int} [id:int] false"}; !reflect.DeepEqual(mounted, want) {
+		t.Errorf("Expected mounted router routes %v, got %v", want, mounted)
+	}
+
+	route := routes[0]
+	if route.Method != fasthttp.MethodGet || route.Pattern != "/users/{id:int}" || route.Name != "user" || len(route.Middleware) != 1 {
+		t.Errorf("Unexpected route %+v", route)
+	}
+
+	if static == nil || !static.Mounted {
+		t.Errorf("Expected mounted handler, got %+v", static)
+	}
+}
+
+func TestFastHTTPRegisterErrors(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", handler.HandleFastHTTP)
+
+	if err := router.Register(fasthttp.MethodGet, "/users/{id}", handler.HandleFastHTTP); !errors.Is(err, ErrDuplicateRoute) {
+		t.Errorf("Expected duplicate route error, got %v", err)
+	}
+	if err := router.Register(fasthttp.MethodGet, "/users/{name}", handler.HandleFastHTTP); !errors.Is(err, mux.ErrConflict) {
+		t.Errorf("Expected conflict error, got %v", err)
+	}
+	if err := router.Register(fasthttp.MethodPost, "/users/{id}", handler.HandleFastHTTP); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	router.Mount("/static", handler.HandleFastHTTP)
+	if err := router.Register(fasthttp.MethodGet, "/static/x", handler.HandleFastHTTP); !errors.Is(err, mux.ErrConflict) {
+		t.Errorf("Expected conflict error for route below mounted handler, got %v", err)
+	}
+}
+
+func TestFastHTTPRemove(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", handler.HandleFastHTTP, WithName("user"))
+	router.POST("/users/{id}", handler.HandleFastHTTP)
+
+	if !router.Remove(fasthttp.MethodGet, "/users/{id}") {
+		t.Fatal("Expected route to be removed")
+	}
+	if router.Remove(fasthttp.MethodGet, "/users/{id}") {
+		t.Error("Route should be removed only once")
+	}
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for removed route, got %d", fasthttp.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	}
+	if _, err := router.URL("user", "id", "1"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Name of removed route should be released, got %v", err)
+	}
+}
+
+func TestFastHTTPConcurrentRegistration(t *testing.T) {
+	t.Parallel()
+
+	ok := func(_ *fasthttp.RequestCtx) {}
+
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", ok)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			pattern := fmt.Sprintf("/tenants/t%d/{id}", i)
+			router.GET(pattern, ok)
+			router.USE(fasthttp.MethodGet, pattern, mockFastHTTPMiddleware("m"))
+			if i%2 == 0 {
+				router.Remove(fasthttp.MethodGet, pattern)
+			}
+		}
+		router.Compile()
+	}()
+
+	for {
+		select {
+		case <-done:
+			if len(router.Routes()) != 51 {
+				t.Errorf("Expected 51 routes, got %d", len(router.Routes()))
+			}
+			return
+		default:
+		}
+
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+		router.HandleFastHTTP(ctx)
+
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("Unexpected status %d", ctx.Response.StatusCode())
+		}
+	}
+}
+
+func TestFastHTTPServeWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/users/{id}", func(_ *fasthttp.RequestCtx) {})
+
+	// changes are published by registration, requests do not take the lock
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	served := make(chan int)
+	go func() {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+		router.HandleFastHTTP(ctx)
+		served <- ctx.Response.StatusCode()
+	}()
+
+	select {
+	case code := <-served:
+		if code != fasthttp.StatusOK {
+			t.Errorf("Unexpected status %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Request waited for the registration lock")
+	}
+}
+
+func TestFastHTTPMiddlewareChain(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		if _, err := fmt.Fprint(ctx, "h"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	router.USE(fasthttp.MethodGet, "/users/{id}", mockFastHTTPMiddleware("2"))
+	router.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("1"))
+
+	serve := func() string {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1")
+		router.HandleFastHTTP(ctx)
+
+		return string(ctx.Response.Body())
+	}
+
+	if got := serve(); got != "21h" {
+		t.Errorf("Expected middleware in registration order, got %q", got)
+	}
+
+	router.USE(fasthttp.MethodGet, "/users", mockFastHTTPMiddleware("3"))
+
+	if got := serve(); got != "213h" {
+		t.Errorf("Expected chain to include middleware added after serving, got %q", got)
+	}
+}
+
+func TestFastHTTPParamsRelease(t *testing.T) {
+	t.Parallel()
+
+	var kept context.Params
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		kept = ctx.UserValue("params").(context.Params).Copy()
+	})
+
+	for _, id := range []string{"1", "2"} {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/"+id)
+		router.HandleFastHTTP(ctx)
+
+		if v := ctx.UserValue("params"); v != nil {
+			t.Errorf("Expected params to be removed after handler returned, got %v", v)
+		}
+		if kept.Value("id") != id {
+			t.Errorf("Expected copied params to keep id %s, got %v", id, kept)
+		}
+	}
+}
+
+func TestFastHTTPCompileStaticRoutes(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if _, err := fmt.Fprint(ctx, body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/healthz", write("healthz"))
+	router.GET("/api/v1/status", write("status"))
+	router.GET("/api/v1/{name}", write("name"))
+	router.USE(fasthttp.MethodGet, "/api", mockFastHTTPMiddleware("m1"))
+	router.Compile()
+
+	if _, ok := router.routing().statics[fasthttp.MethodGet]["api/v1/status"]; !ok {
+		t.Error("Expected static route to be looked up by path")
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/healthz", "healthz"},
+		{"/api/v1/status", "m1status"},
+		{"/api/v1/users", "m1name"},
+	}
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPMatchBytes(t *testing.T) {
+	var kept context.Params
+	router := NewFastHTTPRouter()
+	router.GET("/api/v1/status", func(_ *fasthttp.RequestCtx) {})
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		if kept == nil {
+			kept = ctx.UserValue("params").(context.Params).Copy()
+		}
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1234")
+	router.HandleFastHTTP(ctx)
+
+	// request buffers are reused by fasthttp and path buffers by router,
+	// copied parameter values can not refer to them
+	ctx.URI().SetPath("/users/abcd")
+	router.HandleFastHTTP(buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/wxyz"))
+
+	if kept.Value("id") != "1234" {
+		t.Errorf("Expected parameter value to be kept after request, got %v", kept)
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/api/v1/status")
+	if allocs := testing.AllocsPerRun(100, func() { router.HandleFastHTTP(ctx) }); allocs != 0 {
+		t.Errorf("Expected no allocations for static route, got %v", allocs)
+	}
+}
+
+func TestFastHTTPParamsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("pooled values are dropped randomly with race detector")
+	}
+
+	var id string
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}/posts/{post:[0-9]+}", func(ctx *fasthttp.RequestCtx) {
+		id = ctx.UserValue("params").(context.Params).Value("id")
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/x/posts/1")
+	if allocs := testing.AllocsPerRun(100, func() { router.HandleFastHTTP(ctx) }); allocs != 0 {
+		t.Errorf("Expected no allocations for route with params, got %v", allocs)
+	}
+	if id != "x" {
+		t.Errorf("Expected parameter value, got %q", id)
+	}
+}
+
+func benchmarkFastHTTPMatch(b *testing.B, match func(s *snapshot, root mux.Node, path []byte) mux.Route) {
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/api/v1/users/list", func(_ *fasthttp.RequestCtx) {})
+	router.GET("/api/v1/users/{id}", func(_ *fasthttp.RequestCtx) {})
+
+	s := router.routing()
+	root := s.tree.Find(fasthttp.MethodGet)
+	path := []byte("api/v1/users/list")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if match(s, root, path) == nil {
+			b.Fatal("route not found")
+		}
+	}
+}
+
+func BenchmarkFastHTTPMatchString(b *testing.B) {
+	benchmarkFastHTTPMatch(b, func(s *snapshot, root mux.Node, path []byte) mux.Route {
+		route, _ := s.matchRoute(root, string(path))
+		return route
+	})
+}
+
+func BenchmarkFastHTTPMatchBytes(b *testing.B) {
+	benchmarkFastHTTPMatch(b, func(s *snapshot, root mux.Node, path []byte) mux.Route {
+		buf := acquirePathBuffer(path)
+		defer releasePathBuffer(buf)
+
+		route, _ := s.matchRoute(root, b2s(buf.b))
+		return route
+	})
+}
+
+func TestFastHTTPRouteMetadata(t *testing.T) {
+	t.Parallel()
+
+	var got *context.RouteMetadata
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		got, _ = context.Metadata(ctx)
+	}, WithName("user"), WithScopes("users:read"), WithMetadata("owner", "team-a"))
+	router.GET("/health", func(ctx *fasthttp.RequestCtx) {
+		if _, ok := context.Metadata(ctx); ok {
+			t.Error("Expected no metadata for route registered without options")
+		}
+	})
+
+	router.HandleFastHTTP(buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1"))
+	if got == nil || got.Name != "user" || !reflect.DeepEqual(got.Scopes, []string{"users:read"}) {
+		t.Errorf("Metadata() = %+v", got)
+	}
+	if v, _ := got.Value("owner"); v != "team-a" {
+		t.Errorf("Value(owner) = %v, want team-a", v)
+	}
+
+	router.HandleFastHTTP(buildFastHTTPRequestContext(fasthttp.MethodGet, "/health"))
+
+	if routes := router.Routes(); routes[0].Metadata.Values["owner"] != "team-a" {
+		t.Errorf("Routes() = %+v, want owner metadata", routes)
+	}
+}
+
+func TestFastHTTPRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	var global, handler string
+	write := func(ctx *fasthttp.RequestCtx) {
+		handler, _ = context.Pattern(ctx)
+	}
+
+	sub := NewFastHTTPRouter()
+	sub.GET("/{id}", write)
+
+	router := NewFastHTTPRouter(func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+			global, _ = context.Pattern(ctx)
+		}
+	})
+	router.GET("/users/{id:[0-9]+}", write)
+	router.GET("/", write)
+	router.Mount("/items", sub.HandleFastHTTP)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/1", "/users/{id:[0-9]+}"},
+		{"/", "/"},
+		{"/items/1", "/items/{id}"},
+		{"/missing", ""},
+	}
+	for _, tt := range tests {
+		global, handler = "", ""
+		router.HandleFastHTTP(buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path))
+
+		if global != tt.want || handler != tt.want {
+			t.Errorf("%s: pattern = %q in global middleware, %q in handler, want %q", tt.path, global, handler, tt.want)
+		}
+	}
+
+	// mounted router does not keep the prefix for requests it is passed directly
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/items/1")
+	router.HandleFastHTTP(ctx)
+	ctx.URI().SetPath("/2")
+	sub.HandleFastHTTP(ctx)
+	if handler != "/{id}" {
+		t.Errorf("handler pattern = %q, want /{id}", handler)
+	}
+}
+
+func TestFastHTTPRouteMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := func(_ *fasthttp.RequestCtx) {}
+
+	router := NewFastHTTPRouter()
+	router.USE(fasthttp.MethodPost, "/users", mockFastHTTPMiddleware("1"))
+	router.POST("/users", handler, WithFastHTTPMiddleware(mockFastHTTPMiddleware("a"), mockFastHTTPMiddleware("b")))
+	router.POST("/users/{id}/avatar", handler)
+	router.USE(fasthttp.MethodPost, "/", mockFastHTTPMiddleware("2"))
+
+	for path, want := range map[string]string{"/users": "12ab", "/users/1/avatar": "12"} {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodPost, path)
+		router.HandleFastHTTP(ctx)
+
+		if got := string(ctx.Response.Body()); got != want {
+			t.Errorf("%s: expected middleware %q, got %q", path, want, got)
+		}
+	}
+
+	err := router.Register(fasthttp.MethodGet, "/", handler, WithMiddleware(mockMiddleware("1")))
+	if !errors.Is(err, ErrMiddlewareType) {
+		t.Errorf("Register() error = %v, want ErrMiddlewareType", err)
+	}
+}
+
+func TestFastHTTPGroup(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			fmt.Fprint(ctx, body)
+		}
+	}
+
+	router := NewFastHTTPRouter()
+	router.Group("/api/v2", func(api FastHTTPRouter) {
+		api.GET("/status", write("s"))
+		api.Group("/admin/", func(admin FastHTTPRouter) {
+			admin.GET("/users/{id}", write("u"))
+		}, mockFastHTTPMiddleware("2"))
+	}, mockFastHTTPMiddleware("1"))
+	router.GET("/api/v2/admin/stats", write("x"))
+
+	for path, want := range map[string]string{
+		"/api/v2/status":        "1s",
+		"/api/v2/admin/users/1": "12u",
+		"/api/v2/admin/stats":   "x",
+	} {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, path)
+		router.HandleFastHTTP(ctx)
+
+		if got := string(ctx.Response.Body()); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestFastHTTPGroupMount(t *testing.T) {
+	t.Parallel()
+
+	sub := NewFastHTTPRouter()
+	sub.GET("/ping", func(ctx *fasthttp.RequestCtx) {
+		fmt.Fprint(ctx, "p")
+	})
+
+	router := NewFastHTTPRouter()
+	router.Group("/api", func(api FastHTTPRouter) {
+		api.Group("/admin", func(admin FastHTTPRouter) {
+			admin.Mount("/sub", sub.HandleFastHTTP)
+		}, mockFastHTTPMiddleware("2"))
+	}, mockFastHTTPMiddleware("1"))
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/api/admin/sub/ping")
+	router.HandleFastHTTP(ctx)
+
+	if got := string(ctx.Response.Body()); got != "12p" {
+		t.Errorf("expected mounted handler to run group middleware first, got %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+package gorouter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// group registers routes to the parent Router under prefix,
+// routes are wrapped with middleware of the group before their own ones
+type group struct {
+	Router
+	prefix     string
+	middleware []MiddlewareFunc
+}
+
+func (g *group) POST(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPost, p, f, opts...)
+}
+
+func (g *group) GET(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodGet, p, f, opts...)
+}
+
+func (g *group) PUT(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPut, p, f, opts...)
+}
+
+func (g *group) DELETE(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodDelete, p, f, opts...)
+}
+
+func (g *group) PATCH(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPatch, p, f, opts...)
+}
+
+func (g *group) OPTIONS(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodOptions, p, f, opts...)
+}
+
+func (g *group) HEAD(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodHead, p, f, opts...)
+}
+
+func (g *group) CONNECT(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodConnect, p, f, opts...)
+}
+
+func (g *group) TRACE(p string, f http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodTrace, p, f, opts...)
+}
+
+func (g *group) USE(method, pattern string, fs ...MiddlewareFunc) {
+	g.Router.USE(method, joinPattern(g.prefix, pattern), fs...)
+}
+
+func (g *group) Handle(method, pattern string, h http.Handler, opts ...RouteOption) {
+	if err := g.Register(method, pattern, h, opts...); err != nil {
+		panic(err)
+	}
+}
+
+func (g *group) Register(method, pattern string, h http.Handler, opts ...RouteOption) error {
+	if len(g.middleware) > 0 {
+		opts = append([]RouteOption{WithMiddleware(g.middleware...)}, opts...)
+	}
+
+	return g.Router.Register(method, joinPattern(g.prefix, pattern), h, opts...)
+}
+
+func (g *group) Remove(method, pattern string) bool {
+	return g.Router.Remove(method, joinPattern(g.prefix, pattern))
+}
+
+func (g *group) Mount(pattern string, h http.Handler) {
+	g.mount(pattern, h, nil)
+}
+
+// mount mounts handler to the parent Router wrapped with middleware of the group,
+// followed by middleware of the groups nested within it
+func (g *group) mount(pattern string, h http.Handler, fs []MiddlewareFunc) {
+	fs = append(g.middleware[:len(g.middleware):len(g.middleware)], fs...)
+	g.Router.(interface {
+		mount(pattern string, h http.Handler, fs []MiddlewareFunc)
+	}).mount(joinPattern(g.prefix, pattern), h, fs)
+}
+
+func (g *group) Group(prefix string, fn func(Router), fs ...MiddlewareFunc) {
+	fn(&group{Router: g, prefix: prefix, middleware: fs})
+}
+
+// fastHTTPGroup registers routes to the parent FastHTTPRouter under prefix,
+// routes are wrapped with middleware of the group before their own ones
+type fastHTTPGroup struct {
+	FastHTTPRouter
+	prefix     string
+	middleware []FastHTTPMiddlewareFunc
+}
+
+func (g *fastHTTPGroup) POST(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodPost, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) GET(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodGet, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) PUT(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodPut, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) DELETE(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodDelete, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) PATCH(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodPatch, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) OPTIONS(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodOptions, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) HEAD(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodHead, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) CONNECT(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodConnect, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) TRACE(p string, f fasthttp.RequestHandler, opts ...RouteOption) {
+	g.Handle(fasthttp.MethodTrace, p, f, opts...)
+}
+
+func (g *fastHTTPGroup) USE(method, pattern string, fs ...FastHTTPMiddlewareFunc) {
+	g.FastHTTPRouter.USE(method, joinPattern(g.prefix, pattern), fs...)
+}
+
+func (g *fastHTTPGroup) Handle(method, pattern string, h fasthttp.RequestHandler, opts ...RouteOption) {
+	if err := g.Register(method, pattern, h, opts...); err != nil {
+		panic(err)
+	}
+}
+
+func (g *fastHTTPGroup) Register(method, pattern string, h fasthttp.RequestHandler, opts ...RouteOption) error {
+	if len(g.middleware) > 0 {
+		opts = append([]RouteOption{WithFastHTTPMiddleware(g.middleware...)}, opts...)
+	}
+
+	return g.FastHTTPRouter.Register(method, joinPattern(g.prefix, pattern), h, opts...)
+}
+
+func (g *fastHTTPGroup) Remove(method, pattern string) bool {
+	return g.FastHTTPRouter.Remove(method, joinPattern(g.prefix, pattern))
+}
+
+func (g *fastHTTPGroup) Mount(pattern string, h fasthttp.RequestHandler) {
+	g.mount(pattern, h, nil, nil)
+}
+
+func (g *fastHTTPGroup) MountRouter(pattern string, sub FastHTTPRouter) {
+	g.mount(pattern, sub.HandleFastHTTP, sub, nil)
+}
+
+// mount mounts handler to the parent FastHTTPRouter wrapped with middleware of the group,
+// followed by middleware of the groups nested within it
+func (g *fastHTTPGroup) mount(pattern string, h fasthttp.RequestHandler, sub FastHTTPRouter, fs []FastHTTPMiddlewareFunc) {
+	fs = append(g.middleware[:len(g.middleware):len(g.middleware)], fs...)
+	g.FastHTTPRouter.(interface {
+		mount(pattern string, h fasthttp.RequestHandler, sub FastHTTPRouter, fs []FastHTTPMiddlewareFunc)
+	}).mount(joinPattern(g.prefix, pattern), h, sub, fs)
+}
+
+func (g *fastHTTPGroup) Group(prefix string, fn func(FastHTTPRouter), fs ...FastHTTPMiddlewareFunc) {
+	fn(&fastHTTPGroup{FastHTTPRouter: g, prefix: prefix, middleware: fs})
+}
+
+// joinPattern prefixes pattern with group prefix, which can start with host
+func joinPattern(prefix, pattern string) string {
+	if pattern == "" {
+		return prefix
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(pattern, "/")
+}
@@ -0,0 +1,30 @@
+package gorouter
+
+import (
+	"strings"
+
+	"github.com/vardius/gorouter/v4/mux"
+)
+
+// ValidationError lists issues found in router routes by Validate
+type ValidationError struct {
+	Issues []mux.Issue
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = issue.Error()
+	}
+
+	return "gorouter: invalid routes:\n" + strings.Join(messages, "\n")
+}
+
+// validate returns ValidationError when tree lint reports any issue
+func validate(t mux.Tree) error {
+	if issues := t.Lint(); len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+
+	return nil
+}
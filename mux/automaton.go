@@ -0,0 +1,205 @@
+package mux
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxAutomatonStates limits number of states of automaton combining expressions,
+// expressions requiring more of them are matched one by one
+const maxAutomatonStates = 512
+
+const (
+	// noMember is reported when none of the combined expressions matches value
+	noMember = -1
+	// unknownMember is reported for values automaton can not match, they contain non-ASCII characters
+	unknownMember = -2
+)
+
+// automaton is a deterministic automaton matching ASCII values against many expressions at once,
+// it reports the first of them matching the whole value
+type automaton struct {
+	// next holds transitions of each state for ASCII characters, -1 means no expression can match
+	next [][utf8.RuneSelf]int32
+	// accept holds index of the first expression matching when value ends in the state
+	accept []int
+}
+
+// thread is an instruction of compiled expression program
+type thread struct {
+	member int
+	pc     uint32
+}
+
+// newAutomaton builds automaton for expressions matching the whole value,
+// returns nil if any of them contains empty-width assertion or automaton would be too big
+func newAutomaton(exps []string) *automaton {
+	progs := make([]*syntax.Prog, len(exps))
+	for i, exp := range exps {
+		re, err := syntax.Parse(exp, syntax.Perl)
+		if err != nil {
+			return nil
+		}
+
+		prog, err := syntax.Compile(re.Simplify())
+		if err != nil || hasEmptyWidth(prog) {
+			return nil
+		}
+
+		progs[i] = prog
+	}
+
+	a := &automaton{}
+	states := make(map[string]int32)
+
+	var queue [][]thread
+	add := func(threads []thread) int32 {
+		if len(threads) == 0 {
+			return -1
+		}
+
+		key := stateKey(threads)
+		if id, ok := states[key]; ok {
+			return id
+		}
+
+		id := int32(len(a.accept))
+		states[key] = id
+		a.next = append(a.next, [utf8.RuneSelf]int32{})
+		a.accept = append(a.accept, acceptingMember(progs, threads))
+		queue = append(queue, threads)
+
+		return id
+	}
+
+	var start []thread
+	seen := make(map[thread]bool)
+	for i, prog := range progs {
+		start = closure(prog, i, uint32(prog.Start), start, seen)
+	}
+	add(start)
+
+	for id := 0; id < len(queue); id++ {
+		if len(queue) > maxAutomatonStates {
+			return nil
+		}
+
+		for c := rune(0); c < utf8.RuneSelf; c++ {
+			var next []thread
+			seen := make(map[thread]bool)
+			for _, t := range queue[id] {
+				inst := &progs[t.member].Inst[t.pc]
+				if consumes(inst, c) {
+					next = closure(progs[t.member], t.member, inst.Out, next, seen)
+				}
+			}
+
+			a.next[id][c] = add(next)
+		}
+	}
+
+	return a
+}
+
+// match provides index of the first expression matching the whole value
+func (a *automaton) match(v string) int {
+	var state int32
+
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c >= utf8.RuneSelf {
+			return unknownMember
+		}
+
+		state = a.next[state][c]
+		if state < 0 {
+			return noMember
+		}
+	}
+
+	return a.accept[state]
+}
+
+// closure adds instruction and ones reachable from it without consuming a character,
+// seen holds instructions already visited for the state being built
+func closure(prog *syntax.Prog, member int, pc uint32, threads []thread, seen map[thread]bool) []thread {
+	t := thread{member: member, pc: pc}
+	if seen[t] {
+		return threads
+	}
+	seen[t] = true
+
+	inst := &prog.Inst[pc]
+	switch inst.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		threads = closure(prog, member, inst.Out, threads, seen)
+		return closure(prog, member, inst.Arg, threads, seen)
+	case syntax.InstCapture, syntax.InstNop:
+		return closure(prog, member, inst.Out, threads, seen)
+	case syntax.InstFail:
+		return threads
+	}
+
+	return append(threads, t)
+}
+
+func consumes(inst *syntax.Inst, c rune) bool {
+	switch inst.Op {
+	case syntax.InstRune:
+		return inst.MatchRune(c)
+	case syntax.InstRune1:
+		return c == inst.Rune[0]
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return c != '\n'
+	}
+
+	return false
+}
+
+func acceptingMember(progs []*syntax.Prog, threads []thread) int {
+	member := noMember
+	for _, t := range threads {
+		if progs[t.member].Inst[t.pc].Op == syntax.InstMatch && (member == noMember || t.member < member) {
+			member = t.member
+		}
+	}
+
+	return member
+}
+
+func hasEmptyWidth(prog *syntax.Prog) bool {
+	for _, inst := range prog.Inst {
+		if inst.Op == syntax.InstEmptyWidth {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stateKey identifies state by its sorted instructions
+func stateKey(threads []thread) string {
+	sorted := make([]thread, len(threads))
+	copy(sorted, threads)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].member != sorted[j].member {
+			return sorted[i].member < sorted[j].member
+		}
+		return sorted[i].pc < sorted[j].pc
+	})
+
+	var b strings.Builder
+	for _, t := range sorted {
+		b.WriteString(strconv.Itoa(t.member))
+		b.WriteByte(':')
+		b.WriteString(strconv.FormatUint(uint64(t.pc), 10))
+		b.WriteByte(',')
+	}
+
+	return b.String()
+}
@@ -1,7 +1,10 @@
 package mux
 
 import (
+	"fmt"
 	"testing"
+
+	"github.com/vardius/gorouter/v4/context"
 )
 
 func BenchmarkMux(b *testing.B) {
@@ -41,3 +44,94 @@ func BenchmarkMux(b *testing.B) {
 		}
 	})
 }
+
+// largeRouteSet provides paths of generated admin API, resources share the same prefix
+// and have list, item and nested routes
+func largeRouteSet() (patterns, paths []string) {
+	for i := 0; i < 500; i++ {
+		resource := fmt.Sprintf("resource%d", i)
+
+		patterns = append(patterns,
+			"/admin/"+resource,
+			"/admin/"+resource+"/{id:int}",
+			"/admin/"+resource+"/{id:int}/edit",
+			"/admin/"+resource+"/{id:int}/history/{version}",
+		)
+		paths = append(paths,
+			"admin/"+resource,
+			"admin/"+resource+"/1",
+			"admin/"+resource+"/1/edit",
+			"admin/"+resource+"/1/history/2",
+		)
+	}
+
+	return patterns, paths
+}
+
+func BenchmarkMuxLargeTreeBuild(b *testing.B) {
+	patterns, _ := largeRouteSet()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := NewTree()
+		for _, pattern := range patterns {
+			tree = tree.WithRoute(pattern, &mockRoute{}, 0)
+		}
+	}
+}
+
+func BenchmarkMuxLargeTree(b *testing.B) {
+	patterns, paths := largeRouteSet()
+
+	tree := NewTree()
+	for _, pattern := range patterns {
+		tree = tree.WithRoute(pattern, &mockRoute{}, 0)
+	}
+	tree = tree.Compile()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			route, params := tree.MatchRoute(paths[i%len(paths)])
+			if route == nil {
+				b.Fatalf("route not found for %s", paths[i%len(paths)])
+			}
+			context.ReleaseParams(params)
+			i++
+		}
+	})
+}
+
+func benchmarkMuxRegexpSiblings(compile bool, b *testing.B) {
+	tree := NewTree()
+	for _, pattern := range []string{
+		`{locale:[a-z]{2}_[A-Z]{2}}/home`,
+		`{version:v[0-9]+(\.[0-9]+)?}/status`,
+		`{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}}/details`,
+		`{date:\d{4}-\d{2}-\d{2}}/report`,
+		`{sku:[A-Z]{3}-\d+}/stock`,
+		`{slug:[a-z]+(-[a-z]+)+}/edit`,
+	} {
+		tree = tree.WithRoute(pattern, &mockRoute{}, 0)
+	}
+	if compile {
+		tree = tree.Compile()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			route, params := tree.MatchRoute("hello-world-again/edit")
+			if route == nil {
+				b.Fatal("route not found")
+			}
+			context.ReleaseParams(params)
+		}
+	})
+}
+
+func BenchmarkMuxRegexpSiblings(b *testing.B)         { benchmarkMuxRegexpSiblings(false, b) }
+func BenchmarkMuxRegexpSiblingsCompiled(b *testing.B) { benchmarkMuxRegexpSiblings(true, b) }
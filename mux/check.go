@@ -0,0 +1,140 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+var (
+	// ErrMalformedPattern is returned for route pattern that can not be parsed
+	ErrMalformedPattern = errors.New("malformed pattern")
+	// ErrConflict is returned for pattern competing with already registered one
+	// at the same tree position, e.g. {id} and {name}
+	ErrConflict = errors.New("conflicting pattern")
+)
+
+// CheckPattern validates route pattern without adding it to the Tree
+func CheckPattern(path string) error {
+	_, err := checkPattern(path)
+
+	return err
+}
+
+// CheckHostPattern validates host pattern, e.g. {tenant}.example.com
+func CheckHostPattern(pattern string) error {
+	path, _ := hostPatternToPath(pattern)
+
+	return CheckPattern(path)
+}
+
+// checkPattern parses route pattern checking parameter names and expressions
+func checkPattern(path string) ([]pathutils.Part, error) {
+	parts, err := pathutils.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPattern, err)
+	}
+
+	names := make(map[string]bool)
+
+	for _, part := range parts {
+		for _, segment := range part.Segments {
+			if segment.Kind == pathutils.StaticSegment {
+				continue
+			}
+
+			if names[segment.Name] {
+				return nil, fmt.Errorf("%w: parameter %q used more than once in %q", ErrConflict, segment.Name, path)
+			}
+			names[segment.Name] = true
+
+			if segment.Kind != pathutils.RegexpSegment {
+				continue
+			}
+
+			if _, ok := LookupMatcher(segment.Exp); !ok {
+				if _, err := regexp.Compile(strings.TrimPrefix(segment.Exp, UnanchoredPrefix)); err != nil {
+					return nil, fmt.Errorf("%w: invalid regexp %s at offset %d in %q: %v", ErrMalformedPattern, segment.Raw, segment.Offset, path, err)
+				}
+			}
+		}
+	}
+
+	return parts, nil
+}
+
+// CheckRoute validates route pattern against Tree without modifying it,
+// returns Route already registered under the same pattern.
+// Pattern conflicts with Tree when its parameter competes
+// with a sibling parameter of another name matching the same values,
+// static part shares name with a parameter or pattern is below a mounted handler
+func (t Tree) CheckRoute(path string) (Route, error) {
+	parts, err := checkPattern(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	var node Node
+	tree := t
+
+	for i, part := range parts {
+		node = tree.findPart(part)
+		if node == nil {
+			for _, sibling := range tree.parameters() {
+				if conflicts(sibling, part) {
+					return nil, fmt.Errorf("%w: %s conflicts with %s in %q", ErrConflict, part.Raw, Pattern(sibling), path)
+				}
+			}
+
+			// static part can not share name with parameter,
+			// parameters sharing name with different expressions get separate Nodes
+			if sibling := tree.findName(partName(part)); sibling != nil && (part.IsStatic() || Pattern(sibling) == sibling.Name()) {
+				return nil, fmt.Errorf("%w: %s conflicts with %s in %q", ErrConflict, part.Raw, Pattern(sibling), path)
+			}
+
+			return nil, nil
+		}
+
+		// requests below mounted handler are passed on to it
+		if i < len(parts)-1 && skipsSubPath(node) {
+			return nil, fmt.Errorf("%w: %q is below handler mounted at %s", ErrConflict, path, part.Raw)
+		}
+
+		tree = node.Tree()
+	}
+
+	return node.Route(), nil
+}
+
+// conflicts checks if new path part would compete with Node
+// matching the same values under another parameter name
+func conflicts(n Node, part pathutils.Part) bool {
+	if sub, ok := n.(*subrouterNode); ok {
+		n = sub.Node
+	}
+
+	if len(part.Segments) > 1 {
+		return false
+	}
+
+	segment := part.Segments[0]
+
+	switch node := n.(type) {
+	case *wildcardNode:
+		return segment.Kind == pathutils.ParamSegment
+	case *catchAllNode:
+		return segment.Kind == pathutils.CatchAllSegment
+	case *regexpNode:
+		return segment.Kind == pathutils.RegexpSegment && segment.Exp == node.exp
+	case *matcherNode:
+		return segment.Kind == pathutils.RegexpSegment && segment.Exp == node.matcherName
+	}
+
+	return false
+}
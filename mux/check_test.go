@@ -0,0 +1,60 @@
+package mux
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     error
+	}{
+		{"/", nil},
+		{"/users/{id:int}/{path...}", nil},
+		{"/img/{name}.{ext:png|jpg}", nil},
+		{"/x/{id:(?:a|b)}", nil},
+		{"/x//y", ErrMalformedPattern},
+		{"/x/{id", ErrMalformedPattern},
+		{"/x/id}", ErrMalformedPattern},
+		{"/x/{:int}", ErrMalformedPattern},
+		{"/x/{...}", ErrMalformedPattern},
+		{"/x/{id:[}", ErrMalformedPattern},
+		{"/x/{path...}.png", ErrMalformedPattern},
+		{"/x/{a}{b}", ErrMalformedPattern},
+		{"/x/{id}/{id:int}", ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := CheckPattern(tt.pattern); !errors.Is(err, tt.err) {
+				t.Errorf("CheckPattern() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTreeCheckRoute(t *testing.T) {
+	route := &mockRoute{}
+	tree := NewTree().WithRoute("users/{id}", route, 0)
+
+	if existing, err := tree.CheckRoute("users/{id}"); err != nil || existing != route {
+		t.Errorf("CheckRoute() = %v, %v, want registered route", existing, err)
+	}
+	if existing, err := tree.CheckRoute("users/{id}/posts"); err != nil || existing != nil {
+		t.Errorf("CheckRoute() = %v, %v, want no route", existing, err)
+	}
+	if _, err := tree.CheckRoute("users/{name}"); !errors.Is(err, ErrConflict) {
+		t.Errorf("CheckRoute() error = %v, want %v", err, ErrConflict)
+	}
+	if existing, err := tree.CheckRoute("users/{id:int}"); err != nil || existing != nil {
+		t.Errorf("CheckRoute() = %v, %v, want no route for parameter of another expression", existing, err)
+	}
+	if _, err := tree.CheckRoute("users/id"); !errors.Is(err, ErrConflict) {
+		t.Errorf("CheckRoute() error = %v, want %v", err, ErrConflict)
+	}
+
+	tree = tree.WithSubrouter("static", &mockRoute{}, 0)
+	if _, err := tree.CheckRoute("static/x"); !errors.Is(err, ErrConflict) {
+		t.Errorf("CheckRoute() error = %v, want %v for path below subrouter", err, ErrConflict)
+	}
+}
@@ -0,0 +1,121 @@
+package mux
+
+import (
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
+)
+
+// NewHostNode provides new mux Node matching request host against pattern
+// Pattern labels can be static, named or regexp, e.g. {tenant}.example.com
+// Port is matched only if pattern contains one. Host Node Tree consists of method Nodes
+func NewHostNode(pattern string) HostNode {
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	path, withPort := hostPatternToPath(pattern)
+	labels := NewTree().WithRoute(path, hostRoute{}, 0)
+
+	// single pattern builds single branch, last node knows the parameters count
+	leaf := labels[0]
+	for len(leaf.Tree()) > 0 {
+		leaf = leaf.Tree()[0]
+	}
+
+	return &hostNode{
+		staticNode: &staticNode{
+			name:          pattern,
+			children:      NewTree(),
+			middleware:    middleware.NewCollection(),
+			maxParamsSize: leaf.MaxParamsSize(),
+			index:         &indexCache{},
+		},
+		labels:   labels,
+		withPort: withPort,
+	}
+}
+
+// HostNode represents Node routing requests by host
+type HostNode interface {
+	Node
+
+	// MatchHost matches host to Node pattern, returns host parameters
+	MatchHost(host string) (context.Params, bool)
+}
+
+// hostRoute marks the end of host pattern labels branch
+type hostRoute struct{}
+
+func (hostRoute) Handler() interface{} {
+	return nil
+}
+
+type hostNode struct {
+	*staticNode
+
+	labels   Tree
+	withPort bool
+}
+
+func (n *hostNode) MatchHost(host string) (context.Params, bool) {
+	return n.matchHostPath(hostToPath(host))
+}
+
+func (n *hostNode) matchHostPath(hostPath string) (context.Params, bool) {
+	if !n.withPort {
+		hostPath = stripPort(hostPath)
+	}
+
+	if route, params := n.labels.MatchRoute(hostPath); route != nil {
+		return params, true
+	}
+
+	return nil, false
+}
+
+// MatchRoute does not match paths, host Node Tree consists of method Nodes
+func (n *hostNode) MatchRoute(_ string) (Route, context.Params) {
+	return nil, nil
+}
+
+// MatchMiddleware does not match paths, host Node Tree consists of method Nodes
+func (n *hostNode) MatchMiddleware(_ string) middleware.Collection {
+	return nil
+}
+
+// hostPatternToPath converts host pattern to path so labels can be matched with path Nodes
+// dots separating labels are replaced with slashes, parameters are left untouched
+func hostPatternToPath(pattern string) (path string, withPort bool) {
+	b := []byte(pattern)
+
+	var depth int
+	for i, c := range b {
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case depth == 0 && c == '.':
+			b[i] = '/'
+		case depth == 0 && c == ':':
+			withPort = true
+		}
+	}
+
+	return string(b), withPort
+}
+
+// hostToPath converts request host to path, host names are case insensitive
+func hostToPath(host string) string {
+	return strings.ReplaceAll(strings.ToLower(host), ".", "/")
+}
+
+func stripPort(hostPath string) string {
+	if i := strings.LastIndexByte(hostPath, ':'); i > strings.LastIndexByte(hostPath, ']') {
+		return hostPath[:i]
+	}
+
+	return hostPath
+}
@@ -0,0 +1,68 @@
+package mux
+
+import (
+	"testing"
+)
+
+func TestHostNodeMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		match   bool
+		key     string
+		value   string
+	}{
+		{"example.com", "example.com", true, "", ""},
+		{"example.com", "EXAMPLE.com:8080", true, "", ""},
+		{"example.com", "www.example.com", false, "", ""},
+		{"example.com:8080", "example.com:8080", true, "", ""},
+		{"example.com:8080", "example.com:9090", false, "", ""},
+		{"{tenant}.example.com", "acme.example.com", true, "tenant", "acme"},
+		{"{tenant}.example.com", "example.com", false, "", ""},
+		{"{tenant:[a-z]+}.example.com", "acme.example.com:443", true, "tenant", "acme"},
+		{"{tenant:[a-z]+}.example.com", "123.example.com", false, "", ""},
+		{"{tenant}-api.example.com", "acme-api.example.com", true, "tenant", "acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			params, ok := NewHostNode(tt.pattern).MatchHost(tt.host)
+			if ok != tt.match {
+				t.Fatalf("MatchHost() = %t, want %t", ok, tt.match)
+			}
+			if params.Value(tt.key) != tt.value {
+				t.Errorf("wrong param %s value, expected: %s, actual: %s", tt.key, tt.value, params.Value(tt.key))
+			}
+		})
+	}
+}
+
+func TestTreeMatchHost(t *testing.T) {
+	tree := NewTree().WithRoute("GET/x", &mockRoute{}, 0)
+
+	tree, tenant := tree.WithHost("{tenant}.example.com")
+	tree, api := tree.WithHost("api.example.com")
+
+	if newTree, node := tree.WithHost("api.example.com"); len(newTree) != len(tree) || node.Name() != api.Name() {
+		t.Fatal("WithHost should return copy of existing host node")
+	} else if node == api {
+		t.Fatal("WithHost should not return host node of the original tree")
+	}
+
+	if hostTree, _ := tree.MatchHost("api.example.com"); len(hostTree) != len(api.Tree()) {
+		t.Error("static host should take precedence over host with parameters")
+	}
+
+	tenant.WithChildren(tenant.Tree().WithRoute("GET/y", &mockRoute{}, tenant.MaxParamsSize()))
+
+	hostTree, params := tree.MatchHost("acme.example.com")
+	if route, _ := hostTree.Find("GET").Tree().MatchRoute("y"); route == nil {
+		t.Error("route should match within host tree")
+	}
+	if params.Value("tenant") != "acme" {
+		t.Errorf("wrong param tenant value, expected: acme, actual: %s", params.Value("tenant"))
+	}
+
+	if hostTree, _ := tree.MatchHost("localhost"); hostTree.Find("GET") == nil || len(hostTree) != len(tree) {
+		t.Error("tree should be returned when no host matches")
+	}
+}
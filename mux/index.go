@@ -0,0 +1,379 @@
+package mux
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+)
+
+// minIndexedStatics is the number of static siblings from which
+// looking them up by path part is faster than trying them in turn
+const minIndexedStatics = 8
+
+// maxFoldedPart limits length of path part lowercased for case-insensitive lookup
+const maxFoldedPart = 64
+
+// staticIndex provides static Nodes of a Tree by the first path part they match,
+// it is kept by the first static Node of the Tree
+type staticIndex struct {
+	// tree is the Tree index was built for, statics are nil when it is not indexed
+	tree    Tree
+	statics map[string]Tree
+	// rest contains non-static Nodes in priority order
+	rest Tree
+	fold bool
+}
+
+// indexCache keeps index of the Tree its Node is the first static Node of,
+// Node can be shared by Trees of different versions, each of them replaces index built for the other
+type indexCache struct {
+	index atomic.Value // *staticIndex
+
+	// nodes indexes Tree Nodes for Tree modifications, requests never use it
+	nodes *nodeIndex
+}
+
+// nodeIndex provides Tree Nodes by name in priority order, so Tree modifications look Nodes up
+// without trying them in turn. It is handed over to the Tree replacing the modified one
+type nodeIndex struct {
+	tree  Tree
+	names map[string]Tree
+	// statics is the number of static Nodes preceding the ones with parameters
+	statics int
+	// appendable reports that no other Tree uses spare capacity of the indexed one,
+	// it is known only for Trees provided by modifications
+	appendable bool
+}
+
+// load provides index built for the Tree, nil if there is none
+func (c *indexCache) load(t Tree) *staticIndex {
+	idx, _ := c.index.Load().(*staticIndex)
+	if idx == nil || len(idx.tree) != len(t) || &idx.tree[0] != &t[0] {
+		return nil
+	}
+
+	return idx
+}
+
+func (c *indexCache) store(idx *staticIndex) {
+	c.index.Store(idx)
+}
+
+// candidates provides Nodes that can match path in priority order,
+// static Nodes not matching first path part are skipped when Tree is indexed
+func (t Tree) candidates(path string) (statics Tree, rest Tree) {
+	return t.indexedCandidates(t.staticIndex(), path)
+}
+
+func (t Tree) indexedCandidates(idx *staticIndex, path string) (statics Tree, rest Tree) {
+	if idx == nil {
+		return t, nil
+	}
+
+	part := path
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		part = path[:i]
+	}
+
+	if !idx.fold {
+		return idx.statics[part], idx.rest
+	}
+
+	if len(part) > maxFoldedPart {
+		return t, nil
+	}
+
+	var buf [maxFoldedPart]byte
+
+	return idx.foldedStatics(buf[:copy(buf[:], part)]), idx.rest
+}
+
+// candidatesBytes provides Nodes that can match path like candidates does
+func (t Tree) candidatesBytes(path []byte) (statics Tree, rest Tree) {
+	idx := t.staticIndex()
+	if idx == nil {
+		return t, nil
+	}
+
+	part := path
+	if i := bytes.IndexByte(path, '/'); i >= 0 {
+		part = path[:i]
+	}
+
+	if !idx.fold {
+		return idx.statics[string(part)], idx.rest
+	}
+
+	if len(part) > maxFoldedPart {
+		return t, nil
+	}
+
+	var buf [maxFoldedPart]byte
+
+	return idx.foldedStatics(buf[:copy(buf[:], part)]), idx.rest
+}
+
+// foldedStatics provides static Nodes matching path part case-insensitively,
+// part is lowercased in place
+func (idx *staticIndex) foldedStatics(part []byte) Tree {
+	for i, c := range part {
+		if c >= 0x80 {
+			// indexed names are ASCII, they can not match non-ASCII text even case-insensitively
+			return nil
+		}
+		if 'A' <= c && c <= 'Z' {
+			part[i] = c + 'a' - 'A'
+		}
+	}
+
+	return idx.statics[string(part)]
+}
+
+// staticIndex provides Tree index, it is built the first time Tree is matched.
+// Nil is returned for Tree which is not indexed
+func (t Tree) staticIndex() *staticIndex {
+	cache := t.indexCache()
+	if cache == nil {
+		return nil
+	}
+
+	idx := cache.load(t)
+	if idx == nil {
+		idx = t.newIndex()
+		cache.store(idx)
+	}
+
+	if idx.statics == nil {
+		return nil
+	}
+
+	return idx
+}
+
+// buildIndex indexes Tree static Nodes again, it has to be called after Tree Nodes are modified
+func (t Tree) buildIndex() {
+	if cache := t.indexCache(); cache != nil {
+		cache.store(t.newIndex())
+		cache.nodes = nil
+	}
+}
+
+// newIndex indexes Tree static Nodes, Trees with few static Nodes
+// and case-insensitive Trees with non-ASCII static text are not indexed
+func (t Tree) newIndex() *staticIndex {
+	idx := &staticIndex{tree: t}
+
+	var statics int
+	var fold bool
+	for i, child := range t {
+		n, ok := asStaticNode(child)
+		if !ok {
+			break
+		}
+
+		if i == 0 {
+			fold = n.ignoreCase
+		}
+
+		if n.ignoreCase != fold || (fold && !isASCII(n.name)) {
+			return idx
+		}
+
+		statics++
+	}
+
+	if statics < minIndexedStatics {
+		return idx
+	}
+
+	idx.statics = make(map[string]Tree, statics)
+	idx.rest = t[statics:]
+	idx.fold = fold
+
+	for _, child := range t[:statics] {
+		n, _ := asStaticNode(child)
+		key := idx.key(n.name)
+
+		idx.statics[key] = append(idx.statics[key], child)
+	}
+
+	return idx
+}
+
+// key provides first path part of static Node name
+func (idx *staticIndex) key(name string) string {
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name = name[:i]
+	}
+
+	if idx.fold {
+		return strings.ToLower(name)
+	}
+
+	return name
+}
+
+// dropIndex removes Tree index, it has to be called before Tree Nodes are modified
+func (t Tree) dropIndex() {
+	if cache := t.indexCache(); cache != nil {
+		cache.store((*staticIndex)(nil))
+		cache.nodes = nil
+	}
+}
+
+// named provides Nodes that can have given name, all Nodes of Tree which is not indexed
+func (t Tree) named(name string) Tree {
+	if idx := t.nodeIndex(); idx != nil {
+		return idx.names[name]
+	}
+
+	return t
+}
+
+// parameters provides Nodes that can have parameters, all Nodes of Tree which is not indexed
+func (t Tree) parameters() Tree {
+	if idx := t.nodeIndex(); idx != nil {
+		return t[idx.statics:]
+	}
+
+	return t
+}
+
+// nodeIndex provides index of Tree Nodes by name, Trees with few Nodes are not indexed
+func (t Tree) nodeIndex() *nodeIndex {
+	if len(t) < minIndexedStatics {
+		return nil
+	}
+
+	if idx := t.builtNodeIndex(); idx != nil {
+		return idx
+	}
+
+	idx := &nodeIndex{names: make(map[string]Tree, len(t))}
+	for _, child := range t {
+		if _, ok := asStaticNode(child); ok {
+			idx.statics++
+		}
+
+		idx.names[child.Name()] = append(idx.names[child.Name()], child)
+	}
+
+	if !t.attachNodeIndex(idx, false) {
+		return nil
+	}
+
+	return idx
+}
+
+// builtNodeIndex provides index of Tree Nodes by name if it was already built
+func (t Tree) builtNodeIndex() *nodeIndex {
+	cache := t.indexCache()
+	if cache == nil || cache.nodes == nil {
+		return nil
+	}
+
+	idx := cache.nodes
+	if len(idx.tree) != len(t) || &idx.tree[0] != &t[0] {
+		return nil
+	}
+
+	return idx
+}
+
+// attachNodeIndex hands index over to the Tree, it is kept by the first Node if it is static.
+// Index is valid only for the last Tree it was handed over to
+func (t Tree) attachNodeIndex(idx *nodeIndex, appendable bool) bool {
+	idx.tree = t
+	idx.appendable = appendable
+
+	cache := t.indexCache()
+	if cache == nil {
+		return false
+	}
+
+	cache.nodes = idx
+
+	return true
+}
+
+// insert adds Node inserted to the Tree
+func (idx *nodeIndex) insert(node Node) {
+	if _, ok := asStaticNode(node); ok {
+		idx.statics++
+	}
+
+	bucket := append(idx.names[node.Name()], nil)
+
+	i := len(bucket) - 1
+	for i > 0 && isMoreImportant(node, bucket[i-1]) {
+		i--
+	}
+	copy(bucket[i+1:], bucket[i:])
+	bucket[i] = node
+
+	idx.names[node.Name()] = bucket
+}
+
+// replace replaces Node with its copy
+func (idx *nodeIndex) replace(node, c Node) {
+	for i, child := range idx.names[node.Name()] {
+		if child == node {
+			idx.names[node.Name()][i] = c
+		}
+	}
+}
+
+// remove removes Node removed from the Tree
+func (idx *nodeIndex) remove(node Node) {
+	if _, ok := asStaticNode(node); ok {
+		idx.statics--
+	}
+
+	bucket := idx.names[node.Name()]
+	for i, child := range bucket {
+		if child == node {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+
+	if len(bucket) == 0 {
+		delete(idx.names, node.Name())
+	} else {
+		idx.names[node.Name()] = bucket
+	}
+}
+
+// indexCache provides index cache of the first Tree Node, nil if it is not static
+func (t Tree) indexCache() *indexCache {
+	if len(t) == 0 {
+		return nil
+	}
+
+	if n, ok := asStaticNode(t[0]); ok {
+		return n.index
+	}
+
+	return nil
+}
+
+// asStaticNode provides static Node, including one used by subrouter
+func asStaticNode(n Node) (*staticNode, bool) {
+	if node, ok := n.(*subrouterNode); ok {
+		return asStaticNode(node.Node)
+	}
+
+	node, ok := n.(*staticNode)
+
+	return node, ok
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,294 @@
+package mux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IssueKind classifies problem reported by Tree Lint
+type IssueKind uint8
+
+const (
+	// UnreachableRoute is a route under Node passing the rest of the path to subrouter
+	UnreachableRoute IssueKind = iota
+	// ShadowedRoute is a route which paths are all matched by earlier sibling branch
+	ShadowedRoute
+	// AmbiguousSiblings are sibling regexp or matcher Nodes matching common values,
+	// the earlier one wins for them
+	AmbiguousSiblings
+	// OrphanMiddleware is a middleware registered on branch without routes
+	OrphanMiddleware
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case UnreachableRoute:
+		return "unreachable route"
+	case ShadowedRoute:
+		return "shadowed route"
+	case AmbiguousSiblings:
+		return "ambiguous siblings"
+	case OrphanMiddleware:
+		return "orphan middleware"
+	}
+
+	return "unknown issue"
+}
+
+// Issue is a problem found in Tree by Lint
+type Issue struct {
+	Kind IssueKind
+	// Path is a pattern of affected Node from the Tree root, e.g. GET/users/{id}
+	Path    string
+	Message string
+}
+
+func (i Issue) Error() string {
+	return fmt.Sprintf("%s %s: %s", i.Kind, i.Path, i.Message)
+}
+
+// Lint reports routes that can never be matched, ambiguous regexp and matcher siblings
+// and middleware registered on branches without routes
+func (t Tree) Lint() []Issue {
+	var issues []Issue
+
+	issues = append(issues, t.lintSiblings("")...)
+
+	_ = t.Walk(func(branch []Node) error {
+		n := branch[len(branch)-1]
+		path := branchPattern(branch)
+
+		if n.Route() != nil {
+			for _, ancestor := range branch[:len(branch)-1] {
+				if skipsSubPath(ancestor) {
+					issues = append(issues, Issue{
+						Kind:    UnreachableRoute,
+						Path:    path,
+						Message: fmt.Sprintf("sub paths of %s are passed to subrouter", Pattern(ancestor)),
+					})
+					break
+				}
+			}
+		}
+
+		if len(n.Middleware()) > 0 && !hasRoute(n) {
+			issues = append(issues, Issue{
+				Kind:    OrphanMiddleware,
+				Path:    path,
+				Message: fmt.Sprintf("%d middleware registered on branch without routes", len(n.Middleware())),
+			})
+		}
+
+		issues = append(issues, n.Tree().lintSiblings(path)...)
+
+		return nil
+	})
+
+	return issues
+}
+
+// lintSiblings compares every Node with its later siblings
+func (t Tree) lintSiblings(prefix string) []Issue {
+	var issues []Issue
+
+	for i, earlier := range t {
+		for _, later := range t[i+1:] {
+			covers, overlaps := compareNodes(earlier, later)
+			if !overlaps {
+				continue
+			}
+
+			if !covers {
+				issues = append(issues, Issue{
+					Kind:    AmbiguousSiblings,
+					Path:    joinPattern(prefix, Pattern(later)),
+					Message: fmt.Sprintf("values matched by %s as well are routed to it", Pattern(earlier)),
+				})
+				continue
+			}
+
+			earlierRoutes := routePatterns(earlier, "")
+			for _, pattern := range routePatterns(later, "") {
+				if contains(earlierRoutes, pattern) {
+					issues = append(issues, Issue{
+						Kind:    ShadowedRoute,
+						Path:    joinPattern(joinPattern(prefix, Pattern(later)), pattern),
+						Message: fmt.Sprintf("all paths are matched by %s", joinPattern(joinPattern(prefix, Pattern(earlier)), pattern)),
+					})
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// compareNodes checks if earlier Node matches all (covers) or some (overlaps) path parts matched by later Node,
+// only expressions and built-in matchers which matching can be compared are taken into account
+func compareNodes(earlier, later Node) (covers bool, overlaps bool) {
+	if sub, ok := earlier.(*subrouterNode); ok {
+		return compareNodes(sub.Node, later)
+	}
+	if sub, ok := later.(*subrouterNode); ok {
+		return compareNodes(earlier, sub.Node)
+	}
+
+	switch e := earlier.(type) {
+	case *regexpNode:
+		switch l := later.(type) {
+		case *regexpNode:
+			return compareRegexps(e, l)
+		case *matcherNode:
+			return compareRegexpMatcher(e, l)
+		}
+	case *matcherNode:
+		switch l := later.(type) {
+		case *matcherNode:
+			return compareMatchers(e, l)
+		case *regexpNode:
+			// matchers limit values in ways regexp can not describe, e.g. int overflow
+			_, overlaps := compareRegexpMatcher(l, e)
+			return false, overlaps
+		}
+	}
+
+	return false, false
+}
+
+func compareRegexps(e, l *regexpNode) (covers bool, overlaps bool) {
+	if e.exp == l.exp {
+		return true, true
+	}
+
+	if strings.HasPrefix(e.exp, UnanchoredPrefix) || strings.HasPrefix(l.exp, UnanchoredPrefix) {
+		return false, false
+	}
+
+	if literals := alternationLiterals(l.exp); literals != nil {
+		var matched int
+		for _, literal := range literals {
+			if e.matcher(literal) {
+				matched++
+			}
+		}
+
+		return matched == len(literals), matched > 0
+	}
+
+	if literals := alternationLiterals(e.exp); literals != nil {
+		for _, literal := range literals {
+			if l.matcher(literal) {
+				return false, true
+			}
+		}
+
+		return false, false
+	}
+
+	ec, lc := parseCharClass(e.exp), parseCharClass(l.exp)
+	if ec == nil || lc == nil {
+		return false, false
+	}
+
+	return ec.covers(lc), ec.overlaps(lc)
+}
+
+// compareRegexpMatcher checks if regexp Node matches all or some values accepted by built-in matcher Node
+func compareRegexpMatcher(r *regexpNode, m *matcherNode) (covers bool, overlaps bool) {
+	values, ok := lookupMatcherValues(m.matcherName)
+	if !ok || strings.HasPrefix(r.exp, UnanchoredPrefix) {
+		return false, false
+	}
+
+	for _, sample := range values.samples {
+		if r.matcher(sample) {
+			overlaps = true
+			break
+		}
+	}
+
+	if c := parseCharClass(r.exp); c != nil {
+		covers = c.covers(values.class)
+	}
+
+	return covers, overlaps
+}
+
+// compareMatchers checks if matcher Node accepts all or some values accepted by another one
+func compareMatchers(e, l *matcherNode) (covers bool, overlaps bool) {
+	if e.matcherName == l.matcherName {
+		return true, true
+	}
+
+	for _, pair := range [2][2]*matcherNode{{e, l}, {l, e}} {
+		values, ok := lookupMatcherValues(pair[0].matcherName)
+		if !ok {
+			continue
+		}
+
+		for _, sample := range values.samples {
+			if pair[1].matcher(sample) {
+				return false, true
+			}
+		}
+	}
+
+	return false, false
+}
+
+// routePatterns provides patterns of routes within Node relative to it, empty for Node route
+func routePatterns(n Node, prefix string) []string {
+	var patterns []string
+
+	if n.Route() != nil {
+		patterns = append(patterns, prefix)
+	}
+
+	for _, child := range n.Tree() {
+		patterns = append(patterns, routePatterns(child, joinPattern(prefix, Pattern(child)))...)
+	}
+
+	return patterns
+}
+
+func skipsSubPath(n Node) bool {
+	if sub, ok := n.(*subrouterNode); ok {
+		n = sub.Node
+	}
+
+	if node, ok := n.(interface{ skipsSubPath() bool }); ok {
+		return node.skipsSubPath()
+	}
+
+	return false
+}
+
+func branchPattern(branch []Node) string {
+	parts := make([]string, len(branch))
+	for i, n := range branch {
+		parts[i] = Pattern(n)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+func joinPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+	if pattern == "" {
+		return prefix
+	}
+
+	return prefix + "/" + pattern
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
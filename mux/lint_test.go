@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vardius/gorouter/v4/middleware"
+)
+
+func TestTreeLint(t *testing.T) {
+	m := middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler {
+		return h.(http.Handler)
+	}))
+
+	tree := NewTree().
+		WithRoute("a/{id:\\d+}", &mockRoute{}, 0).
+		WithRoute("a/{num:[0-9]+}", &mockRoute{}, 0).
+		WithRoute("b/{lang:en|pl}", &mockRoute{}, 0).
+		WithRoute("b/{code:[a-z]{2}}", &mockRoute{}, 0).
+		WithRoute("c/{x:[a-z]+}", &mockRoute{}, 0).
+		WithRoute("c/{y:[0-9]+}", &mockRoute{}, 0).
+		WithRoute("d/e", &mockRoute{}, 0).
+		WithMiddleware("f/g", m, 0)
+
+	tree.Find("d").SkipSubPath()
+
+	want := map[IssueKind]string{
+		ShadowedRoute:     "a/{num:[0-9]+}",
+		AmbiguousSiblings: "b/{code:[a-z]{2}}",
+		UnreachableRoute:  "d/e",
+		OrphanMiddleware:  "f/g",
+	}
+
+	issues := tree.Lint()
+	got := make(map[IssueKind]string)
+	for _, issue := range issues {
+		if _, ok := got[issue.Kind]; !ok {
+			got[issue.Kind] = issue.Path
+		}
+	}
+
+	for kind, path := range want {
+		if got[kind] != path {
+			t.Errorf("Expected %s issue for %s, got %q", kind, path, got[kind])
+		}
+	}
+
+	for _, issue := range issues {
+		if issue.Path == "c/{y:[0-9]+}" || issue.Path == "c/{x:[a-z]+}" {
+			t.Errorf("Unexpected issue for disjoint siblings: %s", issue)
+		}
+	}
+}
+
+func TestTreeLintMatchers(t *testing.T) {
+	tree := NewTree().
+		WithRoute("a/{id:int}", &mockRoute{}, 0).
+		WithRoute("a/{n:\\d+}", &mockRoute{}, 0).
+		WithRoute("b/{n:[a-z]+}", &mockRoute{}, 0).
+		WithRoute("b/{id:uint64}", &mockRoute{}, 0).
+		WithRoute("c/{id:uuid}", &mockRoute{}, 0).
+		WithRoute("c/{day:date}", &mockRoute{}, 0).
+		WithRoute("d/{id:int}", &mockRoute{}, 0).
+		WithRoute("d/{n:uint64}", &mockRoute{}, 0)
+
+	got := make(map[string]IssueKind)
+	for _, issue := range tree.Lint() {
+		got[issue.Path] = issue.Kind
+	}
+
+	for _, path := range []string{"a/{n:\\d+}", "d/{n:uint64}"} {
+		if kind, ok := got[path]; !ok || kind != AmbiguousSiblings {
+			t.Errorf("Expected %s issue for %s, got %v", AmbiguousSiblings, path, got)
+		}
+	}
+
+	for _, path := range []string{"b/{n:[a-z]+}", "b/{id:uint64}", "c/{id:uuid}", "c/{day:date}"} {
+		if kind, ok := got[path]; ok {
+			t.Errorf("Unexpected %s issue for disjoint siblings %s", kind, path)
+		}
+	}
+
+	if covers, overlaps := compareNodes(NewNode("{n:[0-9]+}", 0), NewNode("{id:uint64}", 0)); !covers || !overlaps {
+		t.Errorf("Expected [0-9]+ to cover uint64 values, got covers %t overlaps %t", covers, overlaps)
+	}
+	if covers, overlaps := compareNodes(NewNode("{n:[0-9]+}", 0), NewNode("{id:int}", 0)); covers || !overlaps {
+		t.Errorf("Expected [0-9]+ to match some int values, got covers %t overlaps %t", covers, overlaps)
+	}
+}
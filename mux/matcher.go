@@ -0,0 +1,210 @@
+package mux
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Matcher reports whether path parameter value is valid for given type
+type Matcher func(value string) bool
+
+// matcherValues describes values accepted by built-in matcher so Lint can compare it with siblings,
+// class matches every value matcher accepts, samples are some of them
+type matcherValues struct {
+	class   *charClass
+	samples []string
+}
+
+var matchers = struct {
+	sync.RWMutex
+	m      map[string]Matcher
+	values map[string]matcherValues
+}{
+	m: map[string]Matcher{
+		"int":    matchInt,
+		"uint64": matchUint64,
+		"uuid":   matchUUID,
+		"slug":   matchSlug,
+		"date":   matchDate,
+	},
+	values: map[string]matcherValues{
+		"int":    {parseCharClass(`[-+0-9]+`), []string{"0", "-1"}},
+		"uint64": {parseCharClass(`[0-9]+`), []string{"0"}},
+		"uuid":   {parseCharClass(`[-0-9A-Fa-f]{36}`), []string{"123e4567-e89b-12d3-a456-426614174000"}},
+		"slug":   {parseCharClass(`[-0-9a-z]+`), []string{"a", "0", "a-0"}},
+		"date":   {parseCharClass(`[-0-9]{10}`), []string{"2020-01-01"}},
+	},
+}
+
+// RegisterMatcher registers named parameter type
+// that can be used in place of a regular expression, e.g. {id:int}.
+// Matchers are resolved when nodes are created, register them before adding routes.
+// Registering matcher with already used name replaces previous one.
+func RegisterMatcher(name string, m Matcher) {
+	if name == "" {
+		panic("Matcher name can not be empty")
+	}
+	if m == nil {
+		panic("Matcher can not be nil: " + name)
+	}
+
+	matchers.Lock()
+	defer matchers.Unlock()
+
+	matchers.m[name] = m
+	// values of replaced built-in matcher are not known anymore
+	delete(matchers.values, name)
+}
+
+// LookupMatcher returns matcher registered with given name
+func LookupMatcher(name string) (Matcher, bool) {
+	matchers.RLock()
+	defer matchers.RUnlock()
+
+	m, ok := matchers.m[name]
+
+	return m, ok
+}
+
+// lookupMatcherValues returns values description of built-in matcher
+func lookupMatcherValues(name string) (matcherValues, bool) {
+	matchers.RLock()
+	defer matchers.RUnlock()
+
+	v, ok := matchers.values[name]
+
+	return v, ok
+}
+
+// matchInt matches signed decimal integer fitting in int
+func matchInt(value string) bool {
+	if value != "" && (value[0] == '-' || value[0] == '+') {
+		n, ok := parseUint(value[1:])
+		if value[0] == '-' {
+			return ok && n <= 1<<(strconv.IntSize-1)
+		}
+		return ok && n < 1<<(strconv.IntSize-1)
+	}
+
+	n, ok := parseUint(value)
+
+	return ok && n < 1<<(strconv.IntSize-1)
+}
+
+// matchUint64 matches unsigned decimal integer fitting in uint64
+func matchUint64(value string) bool {
+	_, ok := parseUint(value)
+	return ok
+}
+
+// parseUint parses decimal digits checking for uint64 overflow
+func parseUint(value string) (uint64, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var n uint64
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		if n > (1<<64-1)/10 {
+			return 0, false
+		}
+		n *= 10
+		d := uint64(c - '0')
+		if n+d < n {
+			return 0, false
+		}
+		n += d
+	}
+
+	return n, true
+}
+
+// matchUUID matches canonical textual UUID representation
+// e.g. 123e4567-e89b-12d3-a456-426614174000
+func matchUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if value[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(value[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// matchSlug matches lowercase alphanumeric words separated by single hyphens
+// e.g. hello-world-2
+func matchSlug(value string) bool {
+	if value == "" || value[0] == '-' || value[len(value)-1] == '-' {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-':
+			if value[i-1] == '-' {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchDate matches calendar date in YYYY-MM-DD format
+func matchDate(value string) bool {
+	if len(value) != 10 || value[4] != '-' || value[7] != '-' {
+		return false
+	}
+
+	year, ok := parseUint(value[:4])
+	if !ok {
+		return false
+	}
+	month, ok := parseUint(value[5:7])
+	if !ok || month < 1 || month > 12 {
+		return false
+	}
+	day, ok := parseUint(value[8:])
+	if !ok || day < 1 {
+		return false
+	}
+
+	return day <= daysIn(month, year)
+}
+
+func daysIn(month, year uint64) uint64 {
+	switch month {
+	case 2:
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 29
+		}
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	}
+
+	return 31
+}
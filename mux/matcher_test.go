@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		matcher string
+		value   string
+		want    bool
+	}{
+		{"int", "0", true},
+		{"int", "-42", true},
+		{"int", "+42", true},
+		{"int", "9223372036854775807", true},
+		{"int", "-9223372036854775808", true},
+		{"int", "9223372036854775808", false},
+		{"int", "", false},
+		{"int", "-", false},
+		{"int", "4x2", false},
+		{"uint64", "18446744073709551615", true},
+		{"uint64", "18446744073709551616", false},
+		{"uint64", "-1", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123E4567-E89B-12D3-A456-426614174000", true},
+		{"uuid", "123e4567e89b12d3a456426614174000", false},
+		{"uuid", "123e4567-e89b-12d3-a456-42661417400g", false},
+		{"slug", "hello-world-2", true},
+		{"slug", "hello", true},
+		{"slug", "-hello", false},
+		{"slug", "hello-", false},
+		{"slug", "hello--world", false},
+		{"slug", "Hello", false},
+		{"date", "2020-02-29", true},
+		{"date", "2019-02-29", false},
+		{"date", "1900-02-29", false},
+		{"date", "2000-02-29", true},
+		{"date", "2020-04-31", false},
+		{"date", "2020-13-01", false},
+		{"date", "2020-1-01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.matcher+"/"+tt.value, func(t *testing.T) {
+			m, ok := LookupMatcher(tt.matcher)
+			if !ok {
+				t.Fatalf("Matcher %s not registered", tt.matcher)
+			}
+
+			if got := m(tt.value); got != tt.want {
+				t.Errorf("%s(%q) = %t, want %t", tt.matcher, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	RegisterMatcher("lang", func(value string) bool {
+		return value == "en" || value == "pl"
+	})
+
+	if node := NewNode("{lang:lang}", 0); node == nil {
+		t.Fatal("Expected node")
+	} else if _, ok := node.(*matcherNode); !ok {
+		t.Fatalf("Expecting: *mux.matcherNode. Wrong node type: %T\n", node)
+	}
+
+	tree := NewTree().WithRoute("{lang:lang}", &mockRoute{}, 0)
+
+	route, params := tree.MatchRoute("pl")
+	if route == nil || params.Value("lang") != "pl" {
+		t.Fatalf("Expected route with lang parameter, got %v %v", route, params)
+	}
+
+	if route, _ := tree.MatchRoute("de"); route != nil {
+		t.Error("Expected no route for unregistered language")
+	}
+}
+
+func TestTreeMatchTypedBeforeRegexp(t *testing.T) {
+	intRoute := &mockRoute{name: "int"}
+	regexpRoute := &mockRoute{name: "regexp"}
+
+	tree := NewTree().
+		WithRoute("{name:[a-z0-9]+}", regexpRoute, 0).
+		WithRoute("{id:int}", intRoute, 0)
+
+	route, params := tree.MatchRoute("42")
+	if route != intRoute || params.Value("id") != "42" {
+		t.Fatalf("Expected int route, got %v %v", route, params)
+	}
+
+	route, _ = tree.MatchRoute("abc")
+	if route != regexpRoute {
+		t.Fatalf("Expected regexp route, got %v", route)
+	}
+}
@@ -0,0 +1,9 @@
+package mux
+
+type mockRoute struct {
+	name string
+}
+
+func (r *mockRoute) Handler() interface{} {
+	return nil
+}
@@ -2,6 +2,7 @@ package mux
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
@@ -14,27 +15,93 @@ func NewNode(pathPart string, maxParamsSize uint8) Node {
 		return nil
 	}
 
-	name, exp := pathutils.GetNameFromPart(pathPart)
+	part, err := pathutils.ParsePart(pathPart)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return newNode(part, maxParamsSize)
+}
+
+// newNode builds Node for parsed path part
+func newNode(part pathutils.Part, maxParamsSize uint8) Node {
 	static := &staticNode{
-		name:          name,
+		name:          partName(part),
 		children:      NewTree(),
 		middleware:    middleware.NewCollection(),
 		maxParamsSize: maxParamsSize,
+		index:         &indexCache{},
 	}
 
-	var node Node
+	if len(part.Segments) > 1 {
+		return withPattern(static, part.Segments)
+	}
+
+	segment := part.Segments[0]
 
-	if exp != "" {
+	switch segment.Kind {
+	case pathutils.RegexpSegment:
 		static.maxParamsSize++
-		node = withRegexp(static, regexp.MustCompile(exp))
-	} else if name != pathPart {
+		if matcher, ok := LookupMatcher(segment.Exp); ok {
+			return withMatcher(static, segment.Exp, matcher)
+		}
+		return withRegexp(static, segment.Exp)
+	case pathutils.CatchAllSegment:
 		static.maxParamsSize++
-		node = withWildcard(static)
-	} else {
-		node = static
+		return withCatchAll(static)
+	case pathutils.ParamSegment:
+		static.maxParamsSize++
+		return withWildcard(static)
+	}
+
+	return static
+}
+
+// partName provides name of Node created for path part,
+// part mixing static text and parameters is named after the whole part
+func partName(part pathutils.Part) string {
+	if len(part.Segments) == 1 {
+		return part.Segments[0].Name
+	}
+
+	return part.Raw
+}
+
+// matchesPart checks if Node was created from path part comparing parsed segments,
+// so different spellings of the same parameter, e.g. {path*} and {path...}, match
+func matchesPart(n Node, part pathutils.Part) bool {
+	nodePart, err := pathutils.ParsePart(Pattern(n))
+	if err != nil || len(nodePart.Segments) != len(part.Segments) {
+		return false
+	}
+
+	for i, segment := range nodePart.Segments {
+		other := part.Segments[i]
+		if segment.Kind != other.Kind || segment.Name != other.Name || segment.Exp != other.Exp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Pattern provides path part pattern Node was created from, e.g. {id:int}
+// compiled static Nodes provide all merged path parts
+func Pattern(n Node) string {
+	switch node := n.(type) {
+	case *wildcardNode:
+		return "{" + node.name + "}"
+	case *regexpNode:
+		return "{" + node.name + ":" + node.exp + "}"
+	case *matcherNode:
+		return "{" + node.name + ":" + node.matcherName + "}"
+	case *catchAllNode:
+		return "{" + node.name + "...}"
+	case *subrouterNode:
+		return Pattern(node.Node)
 	}
 
-	return node
+	return n.Name()
 }
 
 // RouteAware represents route aware Node
@@ -45,6 +112,7 @@ type RouteAware interface {
 	// Route provides Node's Route if assigned
 	Route() Route
 	// WithRoute assigns Route to given Node
+	// Route implementing RouteMerger is merged with already assigned one
 	WithRoute(r Route)
 
 	// Name provides maximum number of parameters Route can have for given Node
@@ -92,15 +160,23 @@ type staticNode struct {
 
 	maxParamsSize uint8
 	skipSubPath   bool
+	ignoreCase    bool
+
+	// index of the Tree this Node is the first static Node of
+	index *indexCache
 }
 
 func (n *staticNode) MatchRoute(path string) (Route, context.Params) {
 	nameLength := len(n.name)
 	pathLength := len(path)
 
-	if pathLength >= nameLength && n.name == path[:nameLength] {
+	if n.matchName(path) {
 		if nameLength+1 >= pathLength || n.skipSubPath {
-			return n.route, make(context.Params, n.maxParamsSize)
+			if n.route == nil {
+				return nil, nil
+			}
+
+			return n.route, context.AcquireParams(n.maxParamsSize)
 		}
 
 		return n.children.MatchRoute(path[nameLength+1:]) // +1 because we wan to skip slash as well
@@ -109,11 +185,31 @@ func (n *staticNode) MatchRoute(path string) (Route, context.Params) {
 	return nil, nil
 }
 
+// matchRouteBytes matches path to Route within Node and its Tree like MatchRoute does
+func (n *staticNode) matchRouteBytes(path []byte) (Route, context.Params) {
+	nameLength := len(n.name)
+	pathLength := len(path)
+
+	if !n.matchNameBytes(path) {
+		return nil, nil
+	}
+
+	if nameLength+1 >= pathLength || n.skipSubPath {
+		if n.route == nil {
+			return nil, nil
+		}
+
+		return n.route, context.AcquireParams(n.maxParamsSize)
+	}
+
+	return n.children.MatchRouteBytes(path[nameLength+1:]) // +1 because we wan to skip slash as well
+}
+
 func (n *staticNode) MatchMiddleware(path string) middleware.Collection {
 	nameLength := len(n.name)
 	pathLength := len(path)
 
-	if pathLength >= nameLength && n.name == path[:nameLength] {
+	if n.matchName(path) {
 		if nameLength+1 >= pathLength || n.skipSubPath {
 			return n.middleware
 		}
@@ -128,6 +224,38 @@ func (n *staticNode) MatchMiddleware(path string) middleware.Collection {
 	return nil
 }
 
+// matchName checks if path starts with node name followed by path separator or end of path
+func (n *staticNode) matchName(path string) bool {
+	nameLength := len(n.name)
+	pathLength := len(path)
+
+	if pathLength < nameLength {
+		return false
+	}
+
+	if n.name != path[:nameLength] && !(n.ignoreCase && strings.EqualFold(n.name, path[:nameLength])) {
+		return false
+	}
+
+	return pathLength == nameLength || path[nameLength] == '/'
+}
+
+// matchNameBytes checks if path starts with node name like matchName does
+func (n *staticNode) matchNameBytes(path []byte) bool {
+	nameLength := len(n.name)
+	pathLength := len(path)
+
+	if pathLength < nameLength {
+		return false
+	}
+
+	if n.name != string(path[:nameLength]) && !(n.ignoreCase && strings.EqualFold(n.name, string(path[:nameLength]))) {
+		return false
+	}
+
+	return pathLength == nameLength || path[nameLength] == '/'
+}
+
 func (n *staticNode) Name() string {
 	return n.name
 }
@@ -153,6 +281,10 @@ func (n *staticNode) WithChildren(t Tree) {
 }
 
 func (n *staticNode) WithRoute(r Route) {
+	if merger, ok := r.(RouteMerger); ok && n.route != nil {
+		r = merger.MergeRoute(n.route)
+	}
+
 	n.route = r
 }
 
@@ -164,10 +296,38 @@ func (n *staticNode) PrependMiddleware(m middleware.Collection) {
 	n.middleware = m.Merge(n.middleware)
 }
 
+func (n *staticNode) skipsSubPath() bool {
+	return n.skipSubPath
+}
+
+func (n *staticNode) setIgnoreCase(ignoreCase bool) {
+	n.ignoreCase = ignoreCase
+}
+
 func (n *staticNode) SkipSubPath() {
 	n.skipSubPath = true
 }
 
+// clone copies Node with its Tree, middleware collection is copied
+// so appending to it never modifies the original one
+func (n *staticNode) clone() *staticNode {
+	c := n.shallowCopy()
+	c.children = n.children.Clone()
+
+	return c
+}
+
+// shallowCopy copies Node sharing its Tree, middleware collection is copied
+// so appending to it never modifies the original one
+func (n *staticNode) shallowCopy() *staticNode {
+	c := *n
+	c.index = &indexCache{}
+	c.middleware = make(middleware.Collection, len(n.middleware))
+	copy(c.middleware, n.middleware)
+
+	return &c
+}
+
 func withWildcard(parent *staticNode) *wildcardNode {
 	return &wildcardNode{staticNode: parent}
 }
@@ -184,8 +344,12 @@ func (n *wildcardNode) MatchRoute(path string) (Route, context.Params) {
 	var params context.Params
 
 	if subPath == "" || n.staticNode.skipSubPath {
+		if n.route == nil {
+			return nil, nil
+		}
+
 		route = n.route
-		params = make(context.Params, maxParamsSize)
+		params = context.AcquireParams(maxParamsSize)
 	} else {
 		route, params = n.children.MatchRoute(subPath)
 		if route == nil {
@@ -212,33 +376,80 @@ func (n *wildcardNode) MatchMiddleware(path string) middleware.Collection {
 	return n.middleware
 }
 
-func withRegexp(parent *staticNode, regexp *regexp.Regexp) *regexpNode {
+func withRegexp(parent *staticNode, exp string) *regexpNode {
+	re, matcher := compileRegexp(exp)
+
 	return &regexpNode{
 		staticNode: parent,
-		regexp:     regexp,
+		exp:        exp,
+		regexp:     re,
+		matcher:    matcher,
 	}
 }
 
+// regexpNode matches whole path part with regular expression
+// unless expression is prefixed with UnanchoredPrefix
 type regexpNode struct {
 	*staticNode
 
-	regexp *regexp.Regexp
+	exp     string
+	regexp  *regexp.Regexp
+	matcher Matcher
+
+	// group combines expression with the ones of sibling Nodes, member is its index within it
+	group  *regexpGroup
+	member int
 }
 
 func (n *regexpNode) MatchRoute(path string) (Route, context.Params) {
-	pathPart, subPath := pathutils.GetPart(path)
-	if !n.regexp.MatchString(pathPart) {
+	pathPart, _ := pathutils.GetPart(path)
+	if !n.matcher(pathPart) {
+		return nil, nil
+	}
+
+	return n.matchRoute(path)
+}
+
+// matchGrouped matches path using result of expressions group shared with sibling Nodes,
+// group is evaluated once, Nodes preceding the first matching one are skipped
+// and none are matched when group matches nothing
+func (n *regexpNode) matchGrouped(path string, m *groupMatch) (Route, context.Params) {
+	if m.group != n.group {
+		pathPart, _ := pathutils.GetPart(path)
+
+		m.group = n.group
+		m.first = n.group.automaton.match(pathPart)
+	}
+
+	switch {
+	case m.first == noMember:
+		// none of the grouped expressions matches, Node can not match either
 		return nil, nil
+	case m.first == unknownMember || n.member > m.first:
+		return n.MatchRoute(path)
+	case n.member == m.first:
+		return n.matchRoute(path)
 	}
 
+	return nil, nil
+}
+
+// matchRoute matches path which part was already matched with Node expression
+func (n *regexpNode) matchRoute(path string) (Route, context.Params) {
+	pathPart, subPath := pathutils.GetPart(path)
+
 	maxParamsSize := n.MaxParamsSize()
 
 	var route Route
 	var params context.Params
 
 	if subPath == "" || n.staticNode.skipSubPath {
+		if n.route == nil {
+			return nil, nil
+		}
+
 		route = n.route
-		params = make(context.Params, maxParamsSize)
+		params = context.AcquireParams(maxParamsSize)
 	} else {
 		route, params = n.children.MatchRoute(subPath)
 		if route == nil {
@@ -253,7 +464,163 @@ func (n *regexpNode) MatchRoute(path string) (Route, context.Params) {
 
 func (n *regexpNode) MatchMiddleware(path string) middleware.Collection {
 	pathPart, subPath := pathutils.GetPart(path)
-	if !n.regexp.MatchString(pathPart) {
+	if !n.matcher(pathPart) {
+		return nil
+	}
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		return n.middleware
+	}
+
+	if treeMiddleware := n.children.MatchMiddleware(subPath); treeMiddleware != nil {
+		return n.middleware.Merge(treeMiddleware)
+	}
+
+	return n.middleware
+}
+
+func withMatcher(parent *staticNode, matcherName string, matcher Matcher) *matcherNode {
+	return &matcherNode{
+		staticNode:  parent,
+		matcherName: matcherName,
+		matcher:     matcher,
+	}
+}
+
+// matcherNode matches path part with registered Matcher
+// e.g. {id:int}
+type matcherNode struct {
+	*staticNode
+
+	matcherName string
+	matcher     Matcher
+}
+
+func (n *matcherNode) MatchRoute(path string) (Route, context.Params) {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.matcher(pathPart) {
+		return nil, nil
+	}
+
+	maxParamsSize := n.MaxParamsSize()
+
+	var route Route
+	var params context.Params
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		if n.route == nil {
+			return nil, nil
+		}
+
+		route = n.route
+		params = context.AcquireParams(maxParamsSize)
+	} else {
+		route, params = n.children.MatchRoute(subPath)
+		if route == nil {
+			return nil, nil
+		}
+	}
+
+	params.Set(maxParamsSize-1, n.name, pathPart)
+
+	return route, params
+}
+
+func (n *matcherNode) MatchMiddleware(path string) middleware.Collection {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.matcher(pathPart) {
+		return nil
+	}
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		return n.middleware
+	}
+
+	if treeMiddleware := n.children.MatchMiddleware(subPath); treeMiddleware != nil {
+		return n.middleware.Merge(treeMiddleware)
+	}
+
+	return n.middleware
+}
+
+func withPattern(parent *staticNode, segments []pathutils.Segment) *patternNode {
+	parts := make([]patternPart, len(segments))
+	var paramsSize uint8
+
+	for i, segment := range segments {
+		if segment.Kind == pathutils.StaticSegment {
+			parts[i] = patternPart{value: segment.Raw}
+			continue
+		}
+
+		part := patternPart{value: segment.Name, param: true}
+		if segment.Exp != "" {
+			part.matcher = NewMatcher(segment.Exp)
+		}
+
+		parent.maxParamsSize++
+		paramsSize++
+		parts[i] = part
+	}
+
+	return &patternNode{
+		staticNode: parent,
+		parts:      parts,
+		paramsSize: paramsSize,
+	}
+}
+
+type patternPart struct {
+	// value is a static text or parameter name
+	value   string
+	param   bool
+	matcher Matcher
+}
+
+func (p patternPart) matchValue(value string) bool {
+	return value != "" && (p.matcher == nil || p.matcher(value))
+}
+
+// patternNode matches path part mixing static text and parameters
+// e.g. {year}-{month}.{format}
+type patternNode struct {
+	*staticNode
+
+	parts      []patternPart
+	paramsSize uint8
+}
+
+func (n *patternNode) MatchRoute(path string) (Route, context.Params) {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart, nil) {
+		return nil, nil
+	}
+
+	var route Route
+	var params context.Params
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		if n.route == nil {
+			return nil, nil
+		}
+
+		route = n.route
+		params = context.AcquireParams(n.MaxParamsSize())
+	} else {
+		route, params = n.children.MatchRoute(subPath)
+		if route == nil {
+			return nil, nil
+		}
+	}
+
+	n.match(pathPart, params)
+
+	return route, params
+}
+
+func (n *patternNode) MatchMiddleware(path string) middleware.Collection {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart, nil) {
 		return nil
 	}
 
@@ -268,6 +635,129 @@ func (n *regexpNode) MatchMiddleware(path string) middleware.Collection {
 	return n.middleware
 }
 
+// staticLength returns length of static text within node pattern
+func (n *patternNode) staticLength() (length int) {
+	for _, part := range n.parts {
+		if !part.param {
+			length += len(part.value)
+		}
+	}
+
+	return
+}
+
+// match checks if path part matches node pattern,
+// parameter values are set to params when not nil
+func (n *patternNode) match(pathPart string, params context.Params) bool {
+	return matchPatternParts(n.parts, pathPart, params, n.MaxParamsSize()-n.paramsSize, n.ignoreCase)
+}
+
+// matchPatternParts matches parts against value,
+// parameter takes the shortest value followed by the next static text
+func matchPatternParts(parts []patternPart, value string, params context.Params, index uint8, ignoreCase bool) bool {
+	if len(parts) == 0 {
+		return value == ""
+	}
+
+	part := parts[0]
+
+	if !part.param {
+		if !hasPrefix(value, part.value, ignoreCase) {
+			return false
+		}
+
+		return matchPatternParts(parts[1:], value[len(part.value):], params, index, ignoreCase)
+	}
+
+	if len(parts) == 1 {
+		if !part.matchValue(value) {
+			return false
+		}
+
+		if params != nil {
+			params.Set(index, part.value, value)
+		}
+
+		return true
+	}
+
+	next := parts[1].value
+	for i := 1; i < len(value); i++ {
+		j := indexOf(value[i:], next, ignoreCase)
+		if j < 0 {
+			break
+		}
+		i += j
+
+		if part.matchValue(value[:i]) && matchPatternParts(parts[1:], value[i:], params, index+1, ignoreCase) {
+			if params != nil {
+				params.Set(index, part.value, value[:i])
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPrefix(s, prefix string, ignoreCase bool) bool {
+	if ignoreCase {
+		return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+	}
+
+	return strings.HasPrefix(s, prefix)
+}
+
+func indexOf(s, substr string, ignoreCase bool) int {
+	if !ignoreCase {
+		return strings.Index(s, substr)
+	}
+
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func withCatchAll(parent *staticNode) *catchAllNode {
+	return &catchAllNode{staticNode: parent}
+}
+
+// catchAllNode matches the rest of the path including slashes,
+// it has to be the last node of the branch
+type catchAllNode struct {
+	*staticNode
+}
+
+func (n *catchAllNode) MatchRoute(path string) (Route, context.Params) {
+	if path == "" || n.route == nil {
+		return nil, nil
+	}
+
+	maxParamsSize := n.MaxParamsSize()
+	params := context.AcquireParams(maxParamsSize)
+
+	params.Set(maxParamsSize-1, n.name, path)
+
+	return n.route, params
+}
+
+func (n *catchAllNode) MatchMiddleware(path string) middleware.Collection {
+	if path == "" {
+		return nil
+	}
+
+	return n.middleware
+}
+
+func (n *catchAllNode) WithChildren(_ Tree) {
+	panic("Catch-all node can not have children.")
+}
+
 func withSubrouter(parent Node) *subrouterNode {
 	parent.SkipSubPath()
 
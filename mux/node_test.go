@@ -32,4 +32,24 @@ func TestNewNode(t *testing.T) {
 		t.Fatalf("Expecting: *mux.staticNode. Wrong node type: %T\n", node)
 	}
 
+	node = NewNode("{path...}", 0)
+
+	if _, ok := node.(*catchAllNode); !ok {
+		t.Fatalf("Expecting: *mux.catchAllNode. Wrong node type: %T\n", node)
+	}
+
+	if node.Name() != "path" || node.MaxParamsSize() != 1 {
+		t.Fatalf("Unexpected catch-all node name %s or max params size %d\n", node.Name(), node.MaxParamsSize())
+	}
+
+	node = NewNode("{year}-{month:\\d+}.{format}", 1)
+
+	if _, ok := node.(*patternNode); !ok {
+		t.Fatalf("Expecting: *mux.patternNode. Wrong node type: %T\n", node)
+	}
+
+	if node.MaxParamsSize() != 4 {
+		t.Fatalf("Unexpected pattern node max params size %d\n", node.MaxParamsSize())
+	}
+
 }
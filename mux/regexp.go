@@ -0,0 +1,253 @@
+package mux
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnanchoredPrefix marks regular expression that is allowed to match
+// any substring of path part instead of the whole of it, e.g. {name:~^[a-z]}
+const UnanchoredPrefix = "~"
+
+// NewMatcher provides Matcher for parameter expression
+// registered matcher name is resolved first, otherwise expression is compiled as a regular expression
+func NewMatcher(exp string) Matcher {
+	if matcher, ok := LookupMatcher(exp); ok {
+		return matcher
+	}
+
+	_, matcher := compileRegexp(exp)
+
+	return matcher
+}
+
+// compileRegexp compiles regular expression matched against the whole path part
+// simple expressions are matched without regexp engine
+func compileRegexp(exp string) (*regexp.Regexp, Matcher) {
+	if strings.HasPrefix(exp, UnanchoredPrefix) {
+		re := regexp.MustCompile(strings.TrimPrefix(exp, UnanchoredPrefix))
+
+		return re, re.MatchString
+	}
+
+	re := regexp.MustCompile("^(?:" + exp + ")$")
+
+	if matcher := alternationMatcher(exp); matcher != nil {
+		return re, matcher
+	}
+
+	if matcher := charClassMatcher(exp); matcher != nil {
+		return re, matcher
+	}
+
+	return re, re.MatchString
+}
+
+// alternationMatcher recognizes alternation of literals, e.g. en|pl
+func alternationMatcher(exp string) Matcher {
+	values := alternationLiterals(exp)
+
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		value := values[0]
+
+		return func(v string) bool {
+			return v == value
+		}
+	}
+
+	return func(v string) bool {
+		for _, value := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// alternationLiterals provides literals of expression being an alternation of them
+func alternationLiterals(exp string) []string {
+	values := strings.Split(exp, "|")
+
+	for _, value := range values {
+		if value == "" {
+			return nil
+		}
+
+		for i := 0; i < len(value); i++ {
+			if !isLiteral(value[i]) {
+				return nil
+			}
+		}
+	}
+
+	return values
+}
+
+func isLiteral(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_'
+}
+
+// charClassMatcher recognizes repeated ASCII character class, e.g. \d+, [a-z]+ or [0-9]{4}
+func charClassMatcher(exp string) Matcher {
+	if c := parseCharClass(exp); c != nil {
+		return c.match
+	}
+
+	return nil
+}
+
+// charClass is an ASCII character class repeated from min to max times, max -1 is unbounded
+type charClass struct {
+	table    [utf8.RuneSelf]bool
+	min, max int
+}
+
+func parseCharClass(exp string) *charClass {
+	re, err := syntax.Parse(exp, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	c := &charClass{min: 1, max: 1}
+	switch re.Op {
+	case syntax.OpPlus:
+		c.min, c.max = 1, -1
+	case syntax.OpStar:
+		c.min, c.max = 0, -1
+	case syntax.OpRepeat:
+		c.min, c.max = re.Min, re.Max
+	}
+	if re.Op == syntax.OpPlus || re.Op == syntax.OpStar || re.Op == syntax.OpRepeat {
+		if re.Flags&syntax.NonGreedy != 0 || len(re.Sub) != 1 {
+			return nil
+		}
+		re = re.Sub[0]
+	}
+
+	switch re.Op {
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if hi >= utf8.RuneSelf {
+				return nil
+			}
+			for r := lo; r <= hi; r++ {
+				c.table[r] = true
+			}
+		}
+	case syntax.OpLiteral:
+		if len(re.Rune) != 1 || re.Rune[0] >= utf8.RuneSelf || re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		c.table[re.Rune[0]] = true
+	default:
+		return nil
+	}
+
+	return c
+}
+
+func (c *charClass) match(v string) bool {
+	if len(v) < c.min || (c.max >= 0 && len(v) > c.max) {
+		return false
+	}
+
+	for i := 0; i < len(v); i++ {
+		if v[i] >= utf8.RuneSelf || !c.table[v[i]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// covers checks if every value matched by o is matched by c as well
+func (c *charClass) covers(o *charClass) bool {
+	for i := range o.table {
+		if o.table[i] && !c.table[i] {
+			return false
+		}
+	}
+
+	return c.min <= o.min && (c.max < 0 || (o.max >= 0 && o.max <= c.max))
+}
+
+// overlaps checks if any non empty value is matched by both c and o
+func (c *charClass) overlaps(o *charClass) bool {
+	var common bool
+	for i := range o.table {
+		if o.table[i] && c.table[i] {
+			common = true
+			break
+		}
+	}
+
+	lo, hi := c.min, c.max
+	if o.min > lo {
+		lo = o.min
+	}
+	if lo < 1 {
+		lo = 1
+	}
+	if hi < 0 || (o.max >= 0 && o.max < hi) {
+		hi = o.max
+	}
+
+	return common && (hi < 0 || lo <= hi)
+}
+
+// regexpGroup combines regular expressions of sibling regexp Nodes,
+// so the first of them matching path part is found in one pass
+type regexpGroup struct {
+	automaton *automaton
+}
+
+// groupMatch caches regexpGroup result while Tree Nodes are matched against path part
+type groupMatch struct {
+	group *regexpGroup
+	first int
+}
+
+// groupRegexps combines expressions of Tree regexp Nodes matching the whole path part,
+// unanchored expressions are left out
+func (t Tree) groupRegexps() {
+	var nodes []*regexpNode
+	var exps []string
+
+	for _, child := range t {
+		n, ok := child.(*regexpNode)
+		if !ok {
+			continue
+		}
+
+		n.group = nil
+		if strings.HasPrefix(n.exp, UnanchoredPrefix) {
+			continue
+		}
+
+		nodes = append(nodes, n)
+		exps = append(exps, n.exp)
+	}
+
+	if len(nodes) < 2 {
+		return
+	}
+
+	a := newAutomaton(exps)
+	if a == nil {
+		return
+	}
+
+	g := &regexpGroup{automaton: a}
+	for i, n := range nodes {
+		n.group = g
+		n.member = i
+	}
+}
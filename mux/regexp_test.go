@@ -0,0 +1,149 @@
+package mux
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	tests := []struct {
+		exp      string
+		fastPath bool
+	}{
+		{`\d+`, true},
+		{`[a-z]+`, true},
+		{`[A-Za-z0-9_-]*`, true},
+		{`[0-9]{4}`, true},
+		{`\d{2,3}`, true},
+		{`en|pl`, true},
+		{`en`, true},
+		{`r([a-z]+)go`, false},
+		{`\d+?`, false},
+		{`[a-zą]+`, false},
+		{`(?i)en|pl`, false},
+	}
+	values := []string{"", "1", "12", "123", "1234", "abc1", "abc", "ABC", "a_b-c", "en", "pl", "EN", "enpl", "rxgo", "ą"}
+
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			re := regexp.MustCompile("^(?:" + tt.exp + ")$")
+			_, matcher := compileRegexp(tt.exp)
+
+			if fastPath := alternationMatcher(tt.exp) != nil || charClassMatcher(tt.exp) != nil; fastPath != tt.fastPath {
+				t.Errorf("fast path = %t, want %t", fastPath, tt.fastPath)
+			}
+
+			for _, value := range values {
+				if got, want := matcher(value), re.MatchString(value); got != want {
+					t.Errorf("match(%q) = %t, want %t", value, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTreeMatchRegexpWholePart(t *testing.T) {
+	tree := NewTree().WithRoute("{id:\\d+}", &mockRoute{}, 0)
+
+	if route, _ := tree.MatchRoute("abc1"); route != nil {
+		t.Error("Regexp should match the whole path part")
+	}
+
+	if route, params := tree.MatchRoute("12"); route == nil || params.Value("id") != "12" {
+		t.Errorf("Expected route with id parameter, got %v %v", route, params)
+	}
+
+	tree = NewTree().WithRoute("{id:~\\d}", &mockRoute{}, 0)
+
+	if route, params := tree.MatchRoute("abc1"); route == nil || params.Value("id") != "abc1" {
+		t.Errorf("Unanchored regexp should match part of path part, got %v %v", route, params)
+	}
+}
+
+func TestAutomaton(t *testing.T) {
+	exps := []string{`[a-z]{2}_[A-Z]{2}`, `v[0-9]+(\.[0-9]+)?`, `(?i)en|pl`, `\d{4}-\d{2}`, `[a-z]+(-[a-z]+)*`, `[^/]+`}
+	values := []string{"", "en_US", "v1", "v1.2", "v1.", "EN", "pl", "2020-01", "2020-1", "hello", "hello-world", "x.y", "zażółć"}
+
+	a := newAutomaton(exps)
+	if a == nil {
+		t.Fatal("Expected automaton for expressions")
+	}
+
+	for _, value := range values {
+		want := noMember
+		for i, exp := range exps {
+			if regexp.MustCompile("^(?:" + exp + ")$").MatchString(value) {
+				want = i
+				break
+			}
+		}
+		if value == "zażółć" {
+			want = unknownMember
+		}
+
+		if got := a.match(value); got != want {
+			t.Errorf("match(%q) = %d, want %d", value, got, want)
+		}
+	}
+
+	if a := newAutomaton([]string{`\bx`, `y`}); a != nil {
+		t.Error("Expressions with empty-width assertions should not be combined")
+	}
+}
+
+func TestTreeMatchRegexpGroup(t *testing.T) {
+	x, y, z := &mockRoute{name: "x"}, &mockRoute{name: "y"}, &mockRoute{name: "z"}
+	tree := NewTree().
+		WithRoute(`{a:[a-z]+x}/one`, x, 0).
+		WithRoute(`{b:[a-z]+}/two`, y, 0).
+		WithRoute(`{c:~\d}`, z, 0).
+		Compile()
+
+	var grouped int
+	for _, node := range tree {
+		if n, ok := node.(*regexpNode); ok && n.group != nil {
+			grouped++
+		}
+	}
+	if grouped != 2 {
+		t.Errorf("Expected anchored sibling expressions to be combined, %d were", grouped)
+	}
+
+	var calls int
+	for _, node := range tree {
+		if n, ok := node.(*regexpNode); ok && n.group != nil {
+			matcher := n.matcher
+			n.matcher = func(v string) bool {
+				calls++
+				return matcher(v)
+			}
+		}
+	}
+
+	tests := []struct {
+		path  string
+		route Route
+		param string
+	}{
+		{"abx/one", x, "a"},
+		{"abx/two", y, "b"},
+		{"ab/two", y, "b"},
+		{"ab1", z, "c"},
+		{"ą/two", nil, ""},
+		{"12/one", nil, ""},
+	}
+	for _, tt := range tests {
+		calls = 0
+		route, params := tree.MatchRoute(tt.path)
+		if route != tt.route {
+			t.Errorf("route for %s = %v, want %v", tt.path, route, tt.route)
+			continue
+		}
+		if route != nil && params.Value(tt.param) == "" {
+			t.Errorf("Expected %s parameter for %s, got %v", tt.param, tt.path, params)
+		}
+		if tt.path == "12/one" && calls != 0 {
+			t.Errorf("Expected grouped expressions not to be matched one by one when none matches, %d were", calls)
+		}
+	}
+}
@@ -4,3 +4,10 @@ package mux
 type Route interface {
 	Handler() interface{}
 }
+
+// RouteMerger is implemented by Route that can be registered
+// under the same path as already existing Route
+type RouteMerger interface {
+	// MergeRoute merges Route registered earlier under the same path, returns Route to be set
+	MergeRoute(existing Route) Route
+}
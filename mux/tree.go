@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
-	"strings"
 
 	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
@@ -29,8 +28,16 @@ func (t Tree) PrettyPrint() string {
 			_, _ = fmt.Fprintf(buff, "\t%s\n", node.Name())
 		case *wildcardNode:
 			_, _ = fmt.Fprintf(buff, "\t{%s}\n", node.Name())
+		case *matcherNode:
+			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.matcherName)
 		case *regexpNode:
-			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.regexp.String())
+			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.exp)
+		case *catchAllNode:
+			_, _ = fmt.Fprintf(buff, "\t{%s...}\n", node.Name())
+		case *patternNode:
+			_, _ = fmt.Fprintf(buff, "\t%s\n", node.Name())
+		case *hostNode:
+			_, _ = fmt.Fprintf(buff, "\t%s\n", node.Name())
 		case *subrouterNode:
 			_, _ = fmt.Fprintf(buff, "\t_%s\n", node.Name())
 		}
@@ -43,16 +50,46 @@ func (t Tree) PrettyPrint() string {
 	return buff.String()
 }
 
-// Compile optimizes Tree nodes reducing static nodes depth when possible
+// Walk calls fn for every Node of the Tree depth first in priority order,
+// fn receives Nodes on the path from the Tree root down to the visited Node
+func (t Tree) Walk(fn func(branch []Node) error) error {
+	return t.walk(nil, fn)
+}
+
+func (t Tree) walk(branch []Node, fn func(branch []Node) error) error {
+	for _, child := range t {
+		childBranch := append(branch[:len(branch):len(branch)], child)
+
+		if err := fn(childBranch); err != nil {
+			return err
+		}
+
+		if err := child.Tree().walk(childBranch, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compile optimizes Tree nodes reducing static nodes depth when possible,
+// merged nodes are indexed again so they can be looked up by their first path part
+// and expressions of sibling regexp nodes are combined to be matched in one pass
 func (t Tree) Compile() Tree {
 	for i, child := range t {
+		if len(child.Tree()) == 0 {
+			continue
+		}
+
 		child.WithChildren(child.Tree().Compile())
 
 		if len(child.Tree()) == 1 {
 			switch node := child.(type) {
 			case *staticNode:
-				if staticNode, ok := node.Tree()[0].(*staticNode); ok {
+				// node with its own route can not be merged, it would no longer match its own path
+				if staticNode, ok := node.Tree()[0].(*staticNode); ok && node.route == nil {
 					node.WithChildren(staticNode.Tree())
+					node.WithRoute(staticNode.Route())
 					node.AppendMiddleware(staticNode.Middleware())
 					node.name = fmt.Sprintf("%s/%s", node.name, staticNode.name)
 
@@ -66,13 +103,110 @@ func (t Tree) Compile() Tree {
 		}
 	}
 
+	t.buildIndex()
+	t.groupRegexps()
+
+	return t
+}
+
+// Clone returns deep copy of the Tree, Nodes can be modified without affecting the original Tree.
+// Routes, middleware and matchers are shared
+func (t Tree) Clone() Tree {
+	newTree := make(Tree, len(t))
+	for i, child := range t {
+		newTree[i] = cloneNode(child)
+	}
+
+	return newTree
+}
+
+// IgnoreCase makes static text of all Tree Nodes match path case-insensitively,
+// parameter values keep their original case
+func (t Tree) IgnoreCase() Tree {
+	return t.withIgnoreCase(true)
+}
+
+// MatchCase makes static text of all Tree Nodes match path in the case it was registered with,
+// reverting IgnoreCase
+func (t Tree) MatchCase() Tree {
+	return t.withIgnoreCase(false)
+}
+
+// IgnoreCasePath makes static text of Nodes on the path match case-insensitively like IgnoreCase does,
+// e.g. of Nodes added to Tree which already ignores case. Nodes on the path have to be the ones
+// provided by the Tree modification, so Trees they were copied from are not affected
+func (t Tree) IgnoreCasePath(path string) Tree {
+	for _, node := range t.Branch(path) {
+		setIgnoreCase(node, true)
+	}
+
+	return t
+}
+
+func (t Tree) withIgnoreCase(ignore bool) Tree {
+	for _, child := range t {
+		ignoreCase(child, ignore)
+	}
+
+	// static nodes are indexed by path part, lowercased when case is ignored
+	t.buildIndex()
+
 	return t
 }
 
 // MatchRoute path to first Node
+// Nodes are tried in priority order: static, regexp, wildcard.
+// When a branch matches path part but does not contain route for the rest of the path
+// matching falls back to the next sibling
 func (t Tree) MatchRoute(path string) (Route, context.Params) {
+	statics, rest := t.candidates(path)
+
+	var regexps groupMatch
+
+	if route, params := statics.matchRoute(path, &regexps); route != nil {
+		return route, params
+	}
+
+	return rest.matchRoute(path, &regexps)
+}
+
+// MatchRouteBytes matches path to first Node like MatchRoute does,
+// static Nodes are matched without converting path to string.
+// Path is converted once Node with parameters is tried, so parameter values do not refer to it
+func (t Tree) MatchRouteBytes(path []byte) (Route, context.Params) {
+	statics, rest := t.candidatesBytes(path)
+
+	var regexps groupMatch
+	var pathString string
+	var converted bool
+
+	for _, candidates := range [2]Tree{statics, rest} {
+		for _, child := range candidates {
+			if node, ok := child.(*staticNode); ok {
+				if route, params := node.matchRouteBytes(path); route != nil {
+					return route, params
+				}
+
+				continue
+			}
+
+			if !converted {
+				pathString = string(path)
+				converted = true
+			}
+
+			if route, params := matchNode(child, pathString, &regexps); route != nil {
+				return route, params
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (t Tree) matchRoute(path string, regexps *groupMatch) (Route, context.Params) {
 	for _, child := range t {
-		if route, params := child.MatchRoute(path); route != nil {
+		if route, params := matchNode(child, path, regexps); route != nil {
 			return route, params
 		}
 	}
@@ -80,26 +214,115 @@ func (t Tree) MatchRoute(path string) (Route, context.Params) {
 	return nil, nil
 }
 
+// matchNode matches path to Node, regexp Nodes use result of the expressions group they belong to
+func matchNode(n Node, path string, regexps *groupMatch) (Route, context.Params) {
+	if node, ok := n.(*regexpNode); ok && node.group != nil {
+		return node.matchGrouped(path, regexps)
+	}
+
+	return n.MatchRoute(path)
+}
+
 // MatchMiddleware collects middleware from all nodes that match path
+// following the same branch MatchRoute resolves the route within.
+// Middleware from matching branches that do not contain any route is collected as well,
+// branches that were skipped in favor of a sibling are omitted
 func (t Tree) MatchMiddleware(path string) middleware.Collection {
 	var treeMiddleware = make(middleware.Collection, 0)
+	var routeMatched bool
 
-	for _, child := range t {
-		if m := child.MatchMiddleware(path); m != nil {
-			treeMiddleware = treeMiddleware.Merge(m)
+	statics, rest := t.candidates(path)
+
+	for _, candidates := range [2]Tree{statics, rest} {
+		for _, child := range candidates {
+			if !routeMatched {
+				route, params := child.MatchRoute(path)
+				context.ReleaseParams(params)
+
+				if route != nil {
+					routeMatched = true
+
+					if m := child.MatchMiddleware(path); m != nil {
+						treeMiddleware = treeMiddleware.Merge(m)
+					}
+
+					continue
+				}
+			}
+
+			if m := child.MatchMiddleware(path); m != nil && !hasRoute(child) {
+				treeMiddleware = treeMiddleware.Merge(m)
+			}
 		}
 	}
 
 	return treeMiddleware
 }
 
+// MatchHost finds first host Node matching given host
+// returns its Tree of method Nodes and host parameters
+// if there is no matching host Node the Tree itself is returned
+func (t Tree) MatchHost(host string) (Tree, context.Params) {
+	var hostPath string
+
+	for _, child := range t {
+		if node, ok := child.(*hostNode); ok {
+			if hostPath == "" {
+				hostPath = hostToPath(host)
+			}
+
+			if params, ok := node.matchHostPath(hostPath); ok {
+				return node.Tree(), params
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// WithHost returns new Tree with host Node for given pattern
+// if Node does not exist it is created, existing Node is copied so it can be modified
+// without affecting the original Tree
+func (t Tree) WithHost(pattern string) (Tree, HostNode) {
+	for _, child := range t {
+		if node, ok := child.(*hostNode); ok && node.Name() == pattern {
+			newTree, c := t.WithCopy(node)
+
+			return newTree, c.(HostNode)
+		}
+	}
+
+	node := NewHostNode(pattern)
+
+	return t.insert(node), node
+}
+
 // Find finds Node inside a tree by name
 func (t Tree) Find(name string) Node {
 	if name == "" {
 		return nil
 	}
 
-	for _, child := range t {
+	statics, rest := t.candidates(name)
+
+	for _, child := range statics {
+		if child.Name() == name {
+			return child
+		}
+	}
+
+	for _, child := range rest {
+		if child.Name() == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// findName finds Node by name like Find does, Node is looked up as Tree modifications do
+func (t Tree) findName(name string) Node {
+	for _, child := range t.named(name) {
 		if child.Name() == name {
 			return child
 		}
@@ -108,28 +331,75 @@ func (t Tree) Find(name string) Node {
 	return nil
 }
 
+// findPart finds Node created for path part, parameters sharing name
+// with different expressions, e.g. {id} and {id:int}, are kept in separate Nodes
+func (t Tree) findPart(part pathutils.Part) Node {
+	name := partName(part)
+	if name == "" {
+		return nil
+	}
+
+	for _, child := range t.named(name) {
+		if child.Name() != name {
+			continue
+		}
+
+		if part.IsStatic() {
+			if Pattern(child) == name {
+				return child
+			}
+		} else if matchesPart(child, part) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Branch provides Nodes on the path from the Tree root down to the Node created for the path,
+// it ends with the last existing one when Nodes for the rest of the path do not exist
+func (t Tree) Branch(path string) []Node {
+	parts := parsePath(path)
+	branch := make([]Node, 0, len(parts))
+
+	for _, part := range parts {
+		node := t.findPart(part)
+		if node == nil {
+			break
+		}
+
+		branch = append(branch, node)
+		t = node.Tree()
+	}
+
+	return branch
+}
+
 // WithRoute returns new Tree with Route set to Node
 // Route is set to Node under the give path, if Node does not exist it is created
 func (t Tree) WithRoute(path string, route Route, maxParamsSize uint8) Tree {
-	path = pathutils.TrimSlash(path)
-	if path == "" {
+	return t.withRouteParts(parsePath(path), route, maxParamsSize)
+}
+
+func (t Tree) withRouteParts(parts []pathutils.Part, route Route, maxParamsSize uint8) Tree {
+	if len(parts) == 0 {
 		return t
 	}
 
-	parts := strings.Split(path, "/")
-	name, _ := pathutils.GetNameFromPart(parts[0])
-	node := t.Find(name)
-	newTree := t
+	var newTree Tree
 
+	node := t.findPart(parts[0])
 	if node == nil {
-		node = NewNode(parts[0], maxParamsSize)
-		newTree = t.withNode(node).sort()
+		node = newNode(parts[0], maxParamsSize)
+		newTree = t.insert(node)
+	} else {
+		newTree, node = t.WithCopy(node)
 	}
 
 	if len(parts) == 1 {
 		node.WithRoute(route)
 	} else {
-		node.WithChildren(node.Tree().WithRoute(strings.Join(parts[1:], "/"), route, node.MaxParamsSize()))
+		node.WithChildren(node.Tree().withRouteParts(parts[1:], route, node.MaxParamsSize()))
 	}
 
 	return newTree
@@ -138,25 +408,28 @@ func (t Tree) WithRoute(path string, route Route, maxParamsSize uint8) Tree {
 // WithMiddleware returns new Tree with Collection appended to given Node
 // Collection is appended to Node under the give path, if Node does not exist it will panic
 func (t Tree) WithMiddleware(path string, m middleware.Collection, maxParamsSize uint8) Tree {
-	path = pathutils.TrimSlash(path)
-	if path == "" {
+	return t.withMiddlewareParts(parsePath(path), m, maxParamsSize)
+}
+
+func (t Tree) withMiddlewareParts(parts []pathutils.Part, m middleware.Collection, maxParamsSize uint8) Tree {
+	if len(parts) == 0 {
 		return t
 	}
 
-	parts := strings.Split(path, "/")
-	name, _ := pathutils.GetNameFromPart(parts[0])
-	node := t.Find(name)
-	newTree := t
+	var newTree Tree
 
+	node := t.findPart(parts[0])
 	if node == nil {
-		node = NewNode(parts[0], maxParamsSize)
-		newTree = t.withNode(node)
+		node = newNode(parts[0], maxParamsSize)
+		newTree = t.insert(node)
+	} else {
+		newTree, node = t.WithCopy(node)
 	}
 
 	if len(parts) == 1 {
 		node.AppendMiddleware(m)
 	} else {
-		node.WithChildren(node.Tree().WithMiddleware(strings.Join(parts[1:], "/"), m, maxParamsSize))
+		node.WithChildren(node.Tree().withMiddlewareParts(parts[1:], m, node.MaxParamsSize()))
 	}
 
 	return newTree
@@ -165,33 +438,88 @@ func (t Tree) WithMiddleware(path string, m middleware.Collection, maxParamsSize
 // WithSubrouter returns new Tree with new Route set to Subrouter Node
 // Route is set to Node under the give path, ff Node does not exist it is created
 func (t Tree) WithSubrouter(path string, route Route, maxParamsSize uint8) Tree {
-	path = pathutils.TrimSlash(path)
-	if path == "" {
+	return t.withSubrouterParts(parsePath(path), route, maxParamsSize)
+}
+
+func (t Tree) withSubrouterParts(parts []pathutils.Part, route Route, maxParamsSize uint8) Tree {
+	if len(parts) == 0 {
 		return t
 	}
 
-	parts := strings.Split(path, "/")
-	name, _ := pathutils.GetNameFromPart(parts[0])
-	node := t.Find(name)
-	newTree := t
+	var newTree Tree
 
+	node := t.findPart(parts[0])
 	if node == nil {
-		node = NewNode(parts[0], maxParamsSize)
+		node = newNode(parts[0], maxParamsSize)
 		if len(parts) == 1 {
 			node = withSubrouter(node)
 		}
-		newTree = t.withNode(node).sort()
+		newTree = t.insert(node)
+	} else {
+		newTree, node = t.WithCopy(node)
 	}
 
 	if len(parts) == 1 {
 		node.WithRoute(route)
 	} else {
-		node.WithChildren(node.Tree().WithSubrouter(strings.Join(parts[1:], "/"), route, node.MaxParamsSize()))
+		node.WithChildren(node.Tree().withSubrouterParts(parts[1:], route, node.MaxParamsSize()))
 	}
 
 	return newTree
 }
 
+// WithoutRoute returns new Tree without Route under the given path and the removed Route.
+// Nodes left without Route, middleware and children are removed
+func (t Tree) WithoutRoute(path string) (Tree, Route) {
+	return t.withoutRouteParts(parsePath(path))
+}
+
+func (t Tree) withoutRouteParts(parts []pathutils.Part) (Tree, Route) {
+	if len(parts) == 0 {
+		return t, nil
+	}
+
+	node := t.findPart(parts[0])
+	if node == nil {
+		return t, nil
+	}
+
+	var removed Route
+	var children Tree
+	if len(parts) == 1 {
+		removed = node.Route()
+	} else {
+		children, removed = node.Tree().withoutRouteParts(parts[1:])
+	}
+
+	if removed == nil {
+		return t, nil
+	}
+
+	newTree, node := t.WithCopy(node)
+	if len(parts) == 1 {
+		node.WithRoute(nil)
+	} else {
+		node.WithChildren(children)
+	}
+
+	if node.Route() != nil || len(node.Tree()) > 0 || len(node.Middleware()) > 0 {
+		return newTree, removed
+	}
+
+	return newTree.remove(node), removed
+}
+
+// parsePath parses Tree path into parts, panics for malformed path
+func parsePath(path string) []pathutils.Part {
+	parts, err := pathutils.Parse(path)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return parts
+}
+
 // withNode inserts node to Tree
 func (t Tree) withNode(node Node) Tree {
 	if node == nil {
@@ -203,9 +531,76 @@ func (t Tree) withNode(node Node) Tree {
 	return newTree
 }
 
-// Sort sorts nodes in order: static, regexp, wildcard
+// insert returns new Tree with node inserted keeping nodes sorted,
+// Nodes are shared with the original Tree which is not modified
+func (t Tree) insert(node Node) Tree {
+	i := len(t)
+	for i > 0 && isMoreImportant(node, t[i-1]) {
+		i--
+	}
+
+	idx := t.builtNodeIndex()
+
+	var newTree Tree
+	if idx != nil && idx.appendable && i == len(t) {
+		// none of the Tree versions uses its spare capacity, so the Nodes are not copied
+		newTree = append(t, node)
+	} else {
+		newTree = make(Tree, len(t)+1)
+		copy(newTree, t[:i])
+		newTree[i] = node
+		copy(newTree[i+1:], t[i:])
+	}
+
+	// new Tree is either a copy or the only one using capacity of the original Tree
+	if idx != nil && newTree.attachNodeIndex(idx, true) {
+		idx.insert(node)
+	}
+
+	return newTree
+}
+
+// WithCopy returns new Tree with copy of Node in place of the Node, the copy can be modified
+// without affecting the original Tree. It shares its Tree with the Node, so the Tree has to be replaced
+func (t Tree) WithCopy(node Node) (Tree, Node) {
+	c := copyNode(node)
+
+	newTree := make(Tree, len(t))
+	for i, child := range t {
+		if child == node {
+			child = c
+		}
+		newTree[i] = child
+	}
+
+	if idx := t.builtNodeIndex(); idx != nil && newTree.attachNodeIndex(idx, true) {
+		idx.replace(node, c)
+	}
+
+	return newTree, c
+}
+
+// remove returns new Tree without node
+func (t Tree) remove(node Node) Tree {
+	newTree := make(Tree, 0, len(t))
+	for _, child := range t {
+		if child != node {
+			newTree = append(newTree, child)
+		}
+	}
+
+	if idx := t.builtNodeIndex(); idx != nil && len(newTree) > 0 && newTree.attachNodeIndex(idx, true) {
+		idx.remove(node)
+	}
+
+	return newTree
+}
+
+// Sort sorts nodes in order: static, pattern, regexp, wildcard, catch-all
 func (t Tree) sort() Tree {
-	// Sort Nodes in order [statics, regexps, wildcards]
+	t.dropIndex()
+
+	// Sort Nodes in order [statics, patterns, matchers, regexps, wildcards, catch-alls]
 	sort.SliceStable(t, func(i, j int) bool {
 		return isMoreImportant(t[i], t[j])
 	})
@@ -213,6 +608,80 @@ func (t Tree) sort() Tree {
 	return t
 }
 
+func ignoreCase(n Node, ignore bool) {
+	setIgnoreCase(n, ignore)
+
+	n.Tree().withIgnoreCase(ignore)
+}
+
+// setIgnoreCase sets case sensitivity of Node static text, not of its Tree
+func setIgnoreCase(n Node, ignore bool) {
+	if node, ok := n.(*subrouterNode); ok {
+		setIgnoreCase(node.Node, ignore)
+		return
+	}
+
+	if node, ok := n.(interface{ setIgnoreCase(bool) }); ok {
+		node.setIgnoreCase(ignore)
+	}
+}
+
+// cloneNode copies Node with its whole Tree
+func cloneNode(n Node) Node {
+	return copyNodeWith(n, (*staticNode).clone)
+}
+
+// copyNode copies Node sharing its Tree
+func copyNode(n Node) Node {
+	return copyNodeWith(n, (*staticNode).shallowCopy)
+}
+
+func copyNodeWith(n Node, copyStatic func(n *staticNode) *staticNode) Node {
+	switch node := n.(type) {
+	case *staticNode:
+		return copyStatic(node)
+	case *wildcardNode:
+		return &wildcardNode{staticNode: copyStatic(node.staticNode)}
+	case *regexpNode:
+		c := *node
+		c.staticNode = copyStatic(node.staticNode)
+		return &c
+	case *matcherNode:
+		c := *node
+		c.staticNode = copyStatic(node.staticNode)
+		return &c
+	case *patternNode:
+		c := *node
+		c.staticNode = copyStatic(node.staticNode)
+		return &c
+	case *catchAllNode:
+		return &catchAllNode{staticNode: copyStatic(node.staticNode)}
+	case *hostNode:
+		c := *node
+		c.staticNode = copyStatic(node.staticNode)
+		return &c
+	case *subrouterNode:
+		return &subrouterNode{Node: copyNodeWith(node.Node, copyStatic)}
+	}
+
+	return n
+}
+
+// hasRoute checks if Node or any of its descendants has Route assigned
+func hasRoute(n Node) bool {
+	if n.Route() != nil {
+		return true
+	}
+
+	for _, child := range n.Tree() {
+		if hasRoute(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isMoreImportant(left Node, right Node) bool {
 	if leftNode, ok := left.(*subrouterNode); ok {
 		return isMoreImportant(leftNode.Node, right)
@@ -228,15 +697,39 @@ func isMoreImportant(left Node, right Node) bool {
 			return len(leftNode.name) < len(rightNode.name)
 		}
 		return true
+	case *patternNode:
+		switch rightNode := right.(type) {
+		case *staticNode:
+			return false
+		case *patternNode:
+			// more static text makes pattern more specific
+			return leftNode.staticLength() > rightNode.staticLength()
+		}
+		return true
+	case *matcherNode:
+		switch right.(type) {
+		case *regexpNode, *wildcardNode, *catchAllNode:
+			return true
+		}
+		return false
 	case *regexpNode:
-		if _, ok := right.(*wildcardNode); ok {
+		switch rightNode := right.(type) {
+		case *wildcardNode, *catchAllNode:
 			return true
+		case *regexpNode:
+			return len(leftNode.exp) < len(rightNode.exp)
 		}
-		if rightNode, ok := right.(*regexpNode); ok {
-			return len(leftNode.regexp.String()) < len(rightNode.regexp.String())
+		return false
+	case *wildcardNode:
+		_, ok := right.(*catchAllNode)
+		return ok
+	case *hostNode:
+		// static hosts come before hosts with parameters
+		if rightNode, ok := right.(*hostNode); ok {
+			return leftNode.maxParamsSize < rightNode.maxParamsSize
 		}
 		return false
-		// case *wildcardNode:
+		// case *catchAllNode:
 	}
 
 	return false
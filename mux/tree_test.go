@@ -1,7 +1,11 @@
 package mux
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/vardius/gorouter/v4/middleware"
 )
 
 func TestTreeMatch(t *testing.T) {
@@ -43,3 +47,377 @@ func TestTreeMatch(t *testing.T) {
 		t.Fatalf("route did not match expected %s (%s)", "pl/blog/comments/123/new", commentNew.Name())
 	}
 }
+
+func TestTreeMatchBacktracking(t *testing.T) {
+	root := NewNode("GET", 0)
+
+	users := NewNode("users", root.MaxParamsSize())
+	usersNew := NewNode("new", users.MaxParamsSize())
+	org := NewNode("{org}", root.MaxParamsSize())
+	orgRepos := NewNode("repos", org.MaxParamsSize())
+
+	root.WithChildren(root.Tree().withNode(org).sort())
+	root.WithChildren(root.Tree().withNode(users).sort())
+	users.WithChildren(users.Tree().withNode(usersNew).sort())
+	org.WithChildren(org.Tree().withNode(orgRepos).sort())
+
+	usersNew.WithRoute(&mockRoute{})
+	orgRepos.WithRoute(&mockRoute{})
+
+	users.AppendMiddleware(middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h })))
+
+	for _, compile := range []bool{false, true} {
+		if compile {
+			root.WithChildren(root.Tree().Compile())
+		}
+
+		if route, _ := root.Tree().MatchRoute("users/new"); route != usersNew.Route() {
+			t.Errorf("route did not match expected %s (compiled: %t)", "users/new", compile)
+		}
+
+		route, params := root.Tree().MatchRoute("users/repos")
+		if route != orgRepos.Route() {
+			t.Fatalf("route did not match expected %s (compiled: %t)", "users/repos", compile)
+		}
+		if params.Value("org") != "users" {
+			t.Errorf("wrong param value, expected: users, actual: %s (compiled: %t)", params.Value("org"), compile)
+		}
+
+		if m := root.Tree().MatchMiddleware("users/repos"); len(m) != 0 {
+			t.Errorf("middleware from skipped branch should not be collected, got: %d (compiled: %t)", len(m), compile)
+		}
+
+		if m := root.Tree().MatchMiddleware("users/new"); len(m) != 1 {
+			t.Errorf("middleware from matched branch should be collected, got: %d (compiled: %t)", len(m), compile)
+		}
+
+		if route, _ := root.Tree().MatchRoute("users/newer"); route != nil {
+			t.Errorf("route should not match partial path part %s (compiled: %t)", "users/newer", compile)
+		}
+	}
+}
+
+func TestTreeMatchCatchAll(t *testing.T) {
+	tree := NewTree()
+	tree = tree.WithRoute("files/{filepath...}", &mockRoute{}, 0)
+	tree = tree.WithRoute("files/{name}", &mockRoute{}, 0)
+	tree = tree.WithRoute("proxy/{id}/{rest*}", &mockRoute{}, 0)
+
+	tests := []struct {
+		path  string
+		key   string
+		value string
+	}{
+		{"files/a", "name", "a"},
+		{"files/a/b/c.txt", "filepath", "a/b/c.txt"},
+		{"proxy/1/x/y", "rest", "x/y"},
+		{"proxy/1/x/y", "id", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, params := tree.MatchRoute(tt.path)
+			if route == nil {
+				t.Fatalf("route did not match %s", tt.path)
+			}
+			if params.Value(tt.key) != tt.value {
+				t.Errorf("wrong param %s value, expected: %s, actual: %s", tt.key, tt.value, params.Value(tt.key))
+			}
+		})
+	}
+
+	if route, _ := tree.MatchRoute("proxy/1"); route != nil {
+		t.Error("catch-all should not match empty path")
+	}
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("catch-all node should not accept children")
+		}
+	}()
+
+	tree.WithRoute("files/{filepath...}/x", &mockRoute{}, 0)
+}
+
+func TestTreeMatchPattern(t *testing.T) {
+	tree := NewTree()
+	tree = tree.WithRoute("reports/{year}-{month:[0-9]+}.{format}", &mockRoute{}, 0)
+	tree = tree.WithRoute("img/{id}.png", &mockRoute{}, 0)
+	tree = tree.WithRoute("img/{name}", &mockRoute{}, 0)
+	tree = tree.WithRoute("{lang}/v{version}/{file}.{ext}", &mockRoute{}, 0)
+
+	tests := []struct {
+		path   string
+		params map[string]string
+	}{
+		{"reports/2020-01.csv", map[string]string{"year": "2020", "month": "01", "format": "csv"}},
+		{"img/42.png", map[string]string{"id": "42"}},
+		{"img/42.jpg", map[string]string{"name": "42.jpg"}},
+		{"img/.png", map[string]string{"name": ".png"}},
+		{"en/v2/archive.tar.gz", map[string]string{"lang": "en", "version": "2", "file": "archive", "ext": "tar.gz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, params := tree.MatchRoute(tt.path)
+			if route == nil {
+				t.Fatalf("route did not match %s", tt.path)
+			}
+			if len(params) != len(tt.params) {
+				t.Errorf("wrong params size, expected: %d, actual: %d", len(tt.params), len(params))
+			}
+			for key, value := range tt.params {
+				if params.Value(key) != value {
+					t.Errorf("wrong param %s value, expected: %s, actual: %s", key, value, params.Value(key))
+				}
+			}
+		})
+	}
+
+	if route, _ := tree.MatchRoute("reports/2020-xx.csv"); route != nil {
+		t.Error("pattern should not match value not satisfying regexp constraint")
+	}
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("adjacent parameters should panic")
+		}
+	}()
+
+	tree.WithRoute("x/{a}{b}", &mockRoute{}, 0)
+}
+
+func TestTreeMatchIgnoreCase(t *testing.T) {
+	tree := NewTree().
+		WithRoute("blog/{id}/posts", &mockRoute{}, 0).
+		WithRoute("img/{name}.PNG", &mockRoute{}, 0)
+
+	if route, _ := tree.MatchRoute("Blog/AbC/POSTS"); route != nil {
+		t.Fatal("Tree should match case-sensitively by default")
+	}
+
+	tree = tree.IgnoreCase()
+
+	route, params := tree.MatchRoute("Blog/AbC/POSTS")
+	if route == nil || params.Value("id") != "AbC" {
+		t.Errorf("Expected route with id parameter in original case, got %v %v", route, params)
+	}
+
+	route, params = tree.MatchRoute("IMG/Logo.png")
+	if route == nil || params.Value("name") != "Logo" {
+		t.Errorf("Expected route with name parameter in original case, got %v %v", route, params)
+	}
+
+	tree = tree.MatchCase()
+
+	if route, _ := tree.MatchRoute("Blog/AbC/POSTS"); route != nil {
+		t.Error("Tree should match case-sensitively after MatchCase")
+	}
+
+	if route, _ := tree.MatchRoute("img/Logo.PNG"); route == nil {
+		t.Error("Expected route to match registered case after MatchCase")
+	}
+}
+
+func TestTreeWalk(t *testing.T) {
+	tree := NewTree().
+		WithRoute("a/b/{id:int}", &mockRoute{}, 0).
+		WithRoute("a/{name:[a-z]+}/{path...}", &mockRoute{}, 0)
+	tree = tree.Compile()
+
+	var patterns []string
+	err := tree.Walk(func(branch []Node) error {
+		if branch[len(branch)-1].Route() == nil {
+			return nil
+		}
+
+		var parts []string
+		for _, n := range branch {
+			parts = append(parts, Pattern(n))
+		}
+		patterns = append(patterns, strings.Join(parts, "/"))
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a/b/{id:int}", "a/{name:[a-z]+}/{path...}"}
+	if !reflect.DeepEqual(patterns, want) {
+		t.Errorf("Walk() patterns = %v, want %v", patterns, want)
+	}
+}
+
+func TestTreeClone(t *testing.T) {
+	tree := NewTree().WithRoute("a/{id}", &mockRoute{name: "a"}, 0)
+	clone := tree.Clone()
+
+	clone = clone.WithRoute("a/{id}/b", &mockRoute{name: "b"}, 0)
+	clone.Find("a").AppendMiddleware(middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h })))
+
+	if route, _ := tree.MatchRoute("a/1/b"); route != nil {
+		t.Error("Route added to clone should not be added to the original Tree")
+	}
+	if len(tree.Find("a").Middleware()) != 0 {
+		t.Error("Middleware appended to clone should not be appended to the original Tree")
+	}
+	if route, params := clone.MatchRoute("a/1/b"); route == nil || params.Value("id") != "1" {
+		t.Errorf("Expected clone to match route, got %v %v", route, params)
+	}
+}
+
+func TestTreeWithoutRoute(t *testing.T) {
+	a, b := &mockRoute{name: "a"}, &mockRoute{name: "b"}
+	tree := NewTree().
+		WithRoute("a/{id}", a, 0).
+		WithRoute("a/{id}/b", b, 0).
+		WithMiddleware("c", middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h })), 0).
+		WithRoute("c/d", &mockRoute{name: "d"}, 0)
+
+	if _, removed := tree.WithoutRoute("a/{name}"); removed != nil {
+		t.Error("Route should be removed only under the pattern it was registered with")
+	}
+
+	tree, removed := tree.WithoutRoute("a/{id}")
+	if removed != a {
+		t.Fatalf("Expected removed route %v, got %v", a, removed)
+	}
+	if route, _ := tree.MatchRoute("a/1"); route != nil {
+		t.Error("Removed route should not be matched")
+	}
+	if route, _ := tree.MatchRoute("a/1/b"); route != b {
+		t.Error("Child route should be kept")
+	}
+
+	tree, _ = tree.WithoutRoute("a/{id}/b")
+	if tree.Find("a") != nil {
+		t.Error("Node left without routes should be removed")
+	}
+
+	tree, _ = tree.WithoutRoute("c/d")
+	if node := tree.Find("c"); node == nil || len(node.Tree()) != 0 {
+		t.Error("Node with middleware should be kept")
+	}
+}
+
+func TestTreeIndex(t *testing.T) {
+	routes := make(map[string]Route)
+	tree := NewTree()
+	for _, pattern := range []string{"s0", "s1", "s2", "s3", "s3/x", "s4", "s5", "s6", "s7", "deep/a/b", "{id}/edit", "v{version}"} {
+		routes[pattern] = &mockRoute{name: pattern}
+		tree = tree.WithRoute(pattern, routes[pattern], 0)
+	}
+
+	if tree.staticIndex() == nil {
+		t.Fatal("Tree with many static nodes should be indexed")
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+	}{
+		{"s0", "s0"},
+		{"s3/x", "s3/x"},
+		{"s3/edit", "{id}/edit"},
+		{"s7", "s7"},
+		{"deep/a/b", "deep/a/b"},
+		{"v2", "v{version}"},
+	}
+
+	check := func(tree Tree, name string) {
+		for _, tt := range tests {
+			if route, _ := tree.MatchRoute(tt.path); route != routes[tt.pattern] {
+				t.Errorf("%s: route for %s = %v, want %s", name, tt.path, route, tt.pattern)
+			}
+		}
+		if tree.Find("s5") == nil || tree.Find("id") == nil {
+			t.Errorf("%s: Find should find both static and wildcard nodes", name)
+		}
+	}
+
+	check(tree, "registered")
+	check(tree.Clone(), "cloned")
+	check(tree.Clone().Compile(), "compiled")
+
+	tree, _ = tree.WithoutRoute("s0")
+	if route, _ := tree.MatchRoute("s0"); route != nil {
+		t.Error("Removed static route should not be matched")
+	}
+	if route, _ := tree.MatchRoute("s1"); route != routes["s1"] {
+		t.Error("Tree should stay indexed after route is removed")
+	}
+
+	tree = tree.IgnoreCase().WithRoute("s8", &mockRoute{name: "s8"}, 0).IgnoreCase()
+	if tree.staticIndex() == nil || !tree.staticIndex().fold {
+		t.Fatal("Case-insensitive Tree should be indexed by lowercase path part")
+	}
+	if route, _ := tree.MatchRoute("S3/X"); route != routes["s3/x"] {
+		t.Errorf("Case-insensitive Tree should match %s, got %v", "S3/X", route)
+	}
+	if route, _ := tree.MatchRoute("S8"); route == nil {
+		t.Error("Static node added to case-insensitive Tree should be matched")
+	}
+}
+
+func TestTreeVersions(t *testing.T) {
+	tree := NewTree()
+	for _, pattern := range []string{"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9"} {
+		tree = tree.WithRoute(pattern, &mockRoute{name: pattern}, 0)
+	}
+
+	old := tree
+	appended := old.WithRoute("s10", &mockRoute{name: "s10"}, 0)
+	branched := old.WithRoute("s11", &mockRoute{name: "s11"}, 0)
+	modified := appended.WithMiddleware("s0", middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h })), 0)
+	removed, _ := appended.WithoutRoute("s1")
+	appendedAgain := appended.WithRoute("s12", &mockRoute{name: "s12"}, 0)
+
+	tests := []struct {
+		name  string
+		tree  Tree
+		paths map[string]bool
+	}{
+		{"old", old, map[string]bool{"s0": true, "s1": true, "s10": false, "s11": false, "s12": false}},
+		{"appended", appended, map[string]bool{"s0": true, "s1": true, "s10": true, "s11": false, "s12": false}},
+		{"branched", branched, map[string]bool{"s0": true, "s1": true, "s10": false, "s11": true, "s12": false}},
+		{"modified", modified, map[string]bool{"s0": true, "s1": true, "s10": true, "s11": false, "s12": false}},
+		{"removed", removed, map[string]bool{"s0": true, "s1": false, "s10": true, "s11": false, "s12": false}},
+		{"appended again", appendedAgain, map[string]bool{"s0": true, "s1": true, "s10": true, "s11": false, "s12": true}},
+	}
+	for _, tt := range tests {
+		for path, found := range tt.paths {
+			if route, _ := tt.tree.MatchRoute(path); (route != nil) != found {
+				t.Errorf("%s: route for %s matched %t, want %t", tt.name, path, route != nil, found)
+			}
+			if node := tt.tree.Find(path); (node != nil && node.Route() != nil) != found {
+				t.Errorf("%s: node for %s found %t, want %t", tt.name, path, node != nil, found)
+			}
+		}
+	}
+
+	if len(appended.Find("s0").Middleware()) != 0 || len(modified.Find("s0").Middleware()) != 1 {
+		t.Error("Middleware should be added only to the modified Tree")
+	}
+}
+
+func TestTreeMatchRouteBytes(t *testing.T) {
+	tree := NewTree()
+	for _, pattern := range []string{"users", "users/new", "users/{id}", "{org}/repos", "files/{path...}", "img/{name}.png", "a", "b", "c", "d", "e", "f"} {
+		tree = tree.WithRoute(pattern, &mockRoute{name: pattern}, 0)
+	}
+
+	paths := []string{"users", "users/new", "users/1", "users/repos", "x/repos", "files/a/b", "img/logo.png", "f", "Users/New", "g"}
+	for _, ignoreCase := range []bool{false, true} {
+		if ignoreCase {
+			tree = tree.IgnoreCase()
+		}
+
+		for _, path := range paths {
+			want, wantParams := tree.MatchRoute(path)
+			got, gotParams := tree.MatchRouteBytes([]byte(path))
+
+			if got != want || !reflect.DeepEqual(gotParams, wantParams) {
+				t.Errorf("MatchRouteBytes(%s) = %v %v, want %v %v (ignore case: %t)", path, got, gotParams, want, wantParams, ignoreCase)
+			}
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package gorouter
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
@@ -18,104 +21,287 @@ func New(fs ...MiddlewareFunc) Router {
 	r := &router{
 		tree:             mux.NewTree(),
 		globalMiddleware: globalMiddleware,
+		names:            make(namedRoutes),
+		orphans:          make(orphanIndex),
 	}
 
 	r.handler = globalMiddleware.Compose(http.HandlerFunc(r.serveHTTP)).(http.Handler)
+	r.publish()
 
 	return r
 }
 
 type router struct {
+	// mu guards Tree and registration state,
+	// requests are matched using published snapshot without locking.
+	// Snapshot shares the Tree, changed Nodes are copied instead of being modified
+	mu                sync.RWMutex
 	tree              mux.Tree
+	orphans           orphanIndex
+	compiled          bool
+	current           atomic.Value // *snapshot
 	globalMiddleware  middleware.Collection
 	fileServer        http.Handler
 	notFound          http.Handler
 	notAllowed        http.Handler
 	handler           http.Handler
 	middlewareCounter uint
+	names             namedRoutes
+	mounts            []mountedRouter
+	hostRouting       bool
+	pathPolicy        PathPolicy
 }
 
 func (r *router) PrettyPrint() string {
-	return r.tree.PrettyPrint()
+	return r.routing().tree.PrettyPrint()
 }
 
-func (r *router) POST(p string, f http.Handler) {
-	r.Handle(http.MethodPost, p, f)
+func (r *router) POST(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPost, p, f, opts...)
 }
 
-func (r *router) GET(p string, f http.Handler) {
-	r.Handle(http.MethodGet, p, f)
+func (r *router) GET(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodGet, p, f, opts...)
 }
 
-func (r *router) PUT(p string, f http.Handler) {
-	r.Handle(http.MethodPut, p, f)
+func (r *router) PUT(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPut, p, f, opts...)
 }
 
-func (r *router) DELETE(p string, f http.Handler) {
-	r.Handle(http.MethodDelete, p, f)
+func (r *router) DELETE(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodDelete, p, f, opts...)
 }
 
-func (r *router) PATCH(p string, f http.Handler) {
-	r.Handle(http.MethodPatch, p, f)
+func (r *router) PATCH(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPatch, p, f, opts...)
 }
 
-func (r *router) OPTIONS(p string, f http.Handler) {
-	r.Handle(http.MethodOptions, p, f)
+func (r *router) OPTIONS(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodOptions, p, f, opts...)
 }
 
-func (r *router) HEAD(p string, f http.Handler) {
-	r.Handle(http.MethodHead, p, f)
+func (r *router) HEAD(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodHead, p, f, opts...)
 }
 
-func (r *router) CONNECT(p string, f http.Handler) {
-	r.Handle(http.MethodConnect, p, f)
+func (r *router) CONNECT(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodConnect, p, f, opts...)
 }
 
-func (r *router) TRACE(p string, f http.Handler) {
-	r.Handle(http.MethodTrace, p, f)
+func (r *router) TRACE(p string, f http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodTrace, p, f, opts...)
 }
 
-func (r *router) USE(method, path string, fs ...MiddlewareFunc) {
+func (r *router) USE(method, pattern string, fs ...MiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	m := transformMiddlewareFunc(fs...)
 	for i, mf := range m {
 		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
 	}
 
-	r.tree = r.tree.WithMiddleware(method+path, m, 0)
+	host, path := r.splitHostPattern(pattern)
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		return t.WithMiddleware(method+path, m, maxParamsSize)
+	})
+	r.tree = r.pathPolicy.applyPath(r.tree, host, method+path)
+	change.precompose(r.tree, r.orphans, true)
 	r.middlewareCounter += uint(len(m))
+	r.publish()
+}
+
+func (r *router) Handle(method, pattern string, h http.Handler, opts ...RouteOption) {
+	if err := r.Register(method, pattern, h, opts...); err != nil {
+		panic(err)
+	}
 }
 
-func (r *router) Handle(method, path string, h http.Handler) {
-	route := newRoute(h)
+func (r *router) Register(method, pattern string, h http.Handler, opts ...RouteOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route := newRoute(h, opts...)
+	host, path := splitHostPattern(pattern)
+	route.pattern = path
+
+	if err := checkRoute(r.tree, host, method+path, route); err != nil {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, err)
+	}
+	if err := r.names.check(route.name(), path); err != nil {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, err)
+	}
+	if len(route.fastHTTPMiddleware) > 0 {
+		return fmt.Errorf("gorouter: %s %s: %w", method, pattern, ErrMiddlewareType)
+	}
+
+	route.middleware = transformMiddlewareFunc(route.httpMiddleware...)
+	for i, mf := range route.middleware {
+		route.middleware[i] = middleware.WithPriority(mf, r.middlewareCounter)
+	}
+	r.middlewareCounter += uint(len(route.middleware))
+
+	if host != "" {
+		r.hostRouting = true
+	}
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		return t.WithRoute(method+path, route, maxParamsSize)
+	})
+	r.tree = r.pathPolicy.applyPath(r.tree, host, method+path)
+	change.precompose(r.tree, r.orphans, false)
+
+	if route.name() != "" {
+		r.names.add(route.name(), path)
+	}
+
+	r.publish()
+
+	return nil
+}
+
+func (r *router) Remove(method, pattern string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host, path := splitHostPattern(pattern)
+
+	change := newTreeChange(r.tree, host, method+path)
+
+	var removed mux.Route
+	r.tree, removed = withoutRoute(r.tree, host, method+path)
+	if removed == nil {
+		return false
+	}
+
+	change.precompose(r.tree, r.orphans, false)
+
+	if route, ok := removed.(*route); ok && route.named() {
+		r.names.prune(r.tree)
+	}
+	r.publish()
+
+	return true
+}
 
-	r.tree = r.tree.WithRoute(method+path, route, 0)
+func (r *router) Mount(pattern string, h http.Handler) {
+	r.mount(pattern, h, nil)
 }
 
-func (r *router) Mount(path string, h http.Handler) {
-	pathRewrite := newPathSlashesStripper(strings.Count(path, "/"))
+// mount mounts handler wrapped with middleware of the groups it is mounted within
+func (r *router) mount(pattern string, h http.Handler, fs []MiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host, path := r.splitHostPattern(pattern)
+	pathRewrite := newMountRewrite(path)
 	route := newRoute(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.ServeHTTP(w, pathRewrite(r))
 	}))
+	route.middleware = transformMiddlewareFunc(fs...)
+	route.subrouter = h
+	route.router = h
+	route.mount = path
+
+	var changes []treeChange
+
+	r.tree = withHost(r.tree, host, func(t mux.Tree, maxParamsSize uint8) mux.Tree {
+		for _, method := range []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodConnect,
+			http.MethodOptions,
+			http.MethodTrace,
+		} {
+			changes = append(changes, newTreeChange(r.tree, host, method+path))
+			t = t.WithSubrouter(method+path, route, maxParamsSize)
+		}
 
-	for _, method := range []string{
-		http.MethodGet,
-		http.MethodHead,
-		http.MethodPost,
-		http.MethodPut,
-		http.MethodPatch,
-		http.MethodDelete,
-		http.MethodConnect,
-		http.MethodOptions,
-		http.MethodTrace,
-	} {
-		r.tree = r.tree.WithSubrouter(method+path, route, 0)
+		return t
+	})
+	for _, change := range changes {
+		r.tree = r.pathPolicy.applyPath(r.tree, host, change.path)
+		change.precompose(r.tree, r.orphans, false)
 	}
+
+	r.mounts = append(r.mounts, mountedRouter{
+		template: newURLTemplate(path),
+		handler:  h,
+	})
+	r.publish()
+}
+
+func (r *router) Group(prefix string, fn func(Router), fs ...MiddlewareFunc) {
+	fn(&group{Router: r, prefix: prefix, middleware: fs})
+}
+
+func (r *router) URL(name string, params ...string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return buildURL(r.names, r.mounts, name, params...)
+}
+
+func (r *router) Routes() []RouteInfo {
+	var routes []RouteInfo
+
+	_ = r.Walk(func(route RouteInfo) error {
+		routes = append(routes, route)
+		return nil
+	})
+
+	return routes
+}
+
+func (r *router) Walk(fn WalkFunc) error {
+	return walk(r.routing().tree, fn)
+}
+
+func (r *router) Lint() []mux.Issue {
+	return r.routing().tree.Lint()
+}
+
+func (r *router) Validate() error {
+	return validate(r.routing().tree)
 }
 
 func (r *router) Compile() {
-	for i, methodNode := range r.tree {
-		r.tree[i].WithChildren(methodNode.Tree().Compile())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.compiled = true
+	r.publish()
+}
+
+// routing provides published snapshot of routing state
+func (r *router) routing() *snapshot {
+	return r.current.Load().(*snapshot)
+}
+
+// publish builds snapshot of routing state and publishes it for requests,
+// so requests never wait for routing changes. Has to be called with mu locked
+func (r *router) publish() {
+	s := newSnapshot(r.tree, r.compiled, r.hostRouting, r.pathPolicy)
+	r.current.Store(s)
+}
+
+// splitHostPattern splits pattern into host and path, enables host routing when needed
+func (r *router) splitHostPattern(pattern string) (host, path string) {
+	host, path = splitHostPattern(pattern)
+	if host != "" {
+		r.hostRouting = true
 	}
+
+	return host, path
 }
 
 func (r *router) NotFound(notFound http.Handler) {
@@ -126,6 +312,16 @@ func (r *router) NotAllowed(notAllowed http.Handler) {
 	r.notAllowed = notAllowed
 }
 
+func (r *router) PathPolicy(policy PathPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// published Tree is shared with the router one, so policy is applied to its copy
+	r.tree = policy.reapply(r.tree.Clone(), r.pathPolicy)
+	r.pathPolicy = policy
+	r.publish()
+}
+
 func (r *router) ServeFiles(fs http.FileSystem, root string, strip bool) {
 	if root == "" {
 		panic("gorouter.ServeFiles: empty root!")
@@ -138,52 +334,104 @@ func (r *router) ServeFiles(fs http.FileSystem, root string, strip bool) {
 }
 
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// global middleware run before request is routed,
+	// matched pattern is held for them so they can read it once request is handled
+	if len(r.globalMiddleware) > 0 {
+		if ctx, ok := context.AcquirePatternHolder(req.Context()); ok {
+			held := requests.Get().(*http.Request)
+			*held = *req.WithContext(ctx)
+
+			r.handler.ServeHTTP(w, held)
+
+			context.ReleasePatternHolder(ctx)
+			releaseRequest(held)
+			return
+		}
+	}
+
 	r.handler.ServeHTTP(w, req)
 }
 
 func (r *router) serveHTTP(w http.ResponseWriter, req *http.Request) {
 	var path string
 
-	if root := r.tree.Find(req.Method); root != nil {
+	s := r.routing()
+	policy := s.policy
+
+	if clean, ok := policy.cleanPath(req.URL.Path); ok {
+		if policy.redirects(req.Method) {
+			r.redirect(w, req, clean, policy.RedirectCode)
+			return
+		}
+
+		req = withPath(req, clean)
+	}
+
+	tree := s.tree
+	var hostParams context.Params
+	if s.hostRouting {
+		tree, hostParams = s.tree.MatchHost(req.Host)
+		defer context.ReleaseParams(hostParams)
+	}
+
+	if root := tree.Find(req.Method); root != nil {
 		var h http.Handler
+		prefix, _ := context.Prefix(req.Context())
 
 		if req.URL.Path == "/" {
-			if root.Route() != nil && root.Route().Handler() != nil {
-				if r.middlewareCounter > 0 {
-					computedHandler := root.Middleware().Sort().Compose(root.Route().Handler())
+			if rootRoute, unsupported := selectHTTPRoute(root.Route(), req); rootRoute != nil {
+				h = rootRoute.chain.(http.Handler)
 
-					h = computedHandler.(http.Handler)
-				} else {
-					h = root.Route().Handler().(http.Handler)
-				}
-
-				h.ServeHTTP(w, req)
+				routed := withRoute(req, rootRoute.requestPattern(prefix), hostParams, rootRoute)
+				h.ServeHTTP(w, routed)
+				releaseRoute(req, routed)
+				return
+			} else if unsupported {
+				r.serveUnsupportedMediaType(w, req)
 				return
 			}
 		} else {
 			path = pathutils.TrimSlash(req.URL.Path)
 
-			if route, params := root.Tree().MatchRoute(path); route != nil {
-				if r.middlewareCounter > 0 {
-					var allMiddleware middleware.Collection
-					if treeMiddleware := root.Tree().MatchMiddleware(path); len(treeMiddleware) > 0 {
-						allMiddleware = root.Middleware().Merge(treeMiddleware).Sort()
+			matchedRoute, params := s.matchRoute(root, path)
+			defer context.ReleaseParams(params)
+			route, unsupported := selectHTTPRoute(matchedRoute, req)
+			if unsupported {
+				r.serveUnsupportedMediaType(w, req)
+				return
+			}
+
+			if route != nil {
+				if canonical, ok := policy.trailingSlashPath(route, req.URL.Path); ok {
+					if policy.redirects(req.Method) {
+						r.redirect(w, req, canonical, policy.RedirectCode)
 					} else {
-						allMiddleware = root.Middleware().Sort()
+						r.serveNotFound(w, req)
 					}
+					return
+				}
 
-					computedHandler := allMiddleware.Compose(route.Handler())
+				if policy.redirects(req.Method) {
+					if canonical, ok := policy.casePath(route, req.URL.Path, params); ok {
+						r.redirect(w, req, canonical, policy.RedirectCode)
+						return
+					}
+				}
+
+				if route.orphanMiddleware {
+					computedHandler := routeMiddleware(root, path).Compose(route.routeHandler())
 
 					h = computedHandler.(http.Handler)
 				} else {
-					h = route.Handler().(http.Handler)
+					h = route.chain.(http.Handler)
 				}
 
-				if len(params) > 0 {
-					req = req.WithContext(context.WithParams(req.Context(), params))
-				}
+				// host parameters take the first indexes of params
+				copy(params, hostParams)
 
-				h.ServeHTTP(w, req)
+				routed := withRoute(req, route.requestPattern(prefix), params, route)
+				h.ServeHTTP(w, routed)
+				releaseRoute(req, routed)
 				return
 			}
 		}
@@ -198,7 +446,7 @@ func (r *router) serveHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Handle OPTIONS
-	if allow := allowed(r.tree, req.Method, path); len(allow) > 0 {
+	if allow := allowed(tree, req.Method, path); len(allow) > 0 {
 		w.Header().Set("Allow", allow)
 
 		if req.Method == http.MethodOptions {
@@ -214,6 +462,15 @@ func (r *router) serveHTTP(w http.ResponseWriter, req *http.Request) {
 	r.serveNotFound(w, req)
 }
 
+// redirect redirects request to given path keeping query string
+func (r *router) redirect(w http.ResponseWriter, req *http.Request, path string, code int) {
+	u := *req.URL
+	u.Path = path
+	u.RawPath = ""
+
+	http.Redirect(w, req, u.String(), code)
+}
+
 func (r *router) serveNotFound(w http.ResponseWriter, req *http.Request) {
 	if r.notFound != nil {
 		r.notFound.ServeHTTP(w, req)
@@ -222,6 +479,13 @@ func (r *router) serveNotFound(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (r *router) serveUnsupportedMediaType(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w,
+		http.StatusText(http.StatusUnsupportedMediaType),
+		http.StatusUnsupportedMediaType,
+	)
+}
+
 func (r *router) serveNotAllowed(w http.ResponseWriter, req *http.Request) {
 	if r.notAllowed != nil {
 		r.notAllowed.ServeHTTP(w, req)
@@ -233,6 +497,62 @@ func (r *router) serveNotAllowed(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// withRoute stores pattern, Params and metadata of route matched for request in its context.
+// Request copy and its context are taken from pools and released with releaseRoute once handler returns.
+// Pattern is set to the holder of global middleware if request has one,
+// request is not copied then unless there are Params or metadata to store
+func withRoute(req *http.Request, pattern string, params context.Params, r *route) *http.Request {
+	if len(params) == 0 {
+		params = nil
+	}
+
+	held := context.SetPattern(req.Context(), pattern)
+	if params == nil && r.meta == nil && held {
+		return req
+	}
+	if held {
+		pattern = ""
+	}
+
+	routed := requests.Get().(*http.Request)
+	*routed = *req.WithContext(context.AcquireRoute(req.Context(), pattern, params, r.meta))
+
+	return routed
+}
+
+// requests pools request copies carrying context of the route matched for request
+var requests = sync.Pool{
+	New: func() interface{} {
+		return new(http.Request)
+	},
+}
+
+// releaseRoute puts request copy provided by withRoute and its context back to the pools
+// once handler returns
+func releaseRoute(req, routed *http.Request) {
+	if routed == req {
+		return
+	}
+
+	context.ReleaseRoute(routed.Context())
+	releaseRequest(routed)
+}
+
+// releaseRequest puts pooled request copy back to the pool once handler returns
+func releaseRequest(r *http.Request) {
+	*r = http.Request{}
+	requests.Put(r)
+}
+
+// selectHTTPRoute selects route or one of its alternatives which constraints request satisfies
+func selectHTTPRoute(matched mux.Route, req *http.Request) (*route, bool) {
+	if r, ok := matched.(*route); ok {
+		return r.matchHTTP(req)
+	}
+
+	return nil, false
+}
+
 func transformMiddlewareFunc(fs ...MiddlewareFunc) middleware.Collection {
 	m := make(middleware.Collection, len(fs))
 
@@ -247,20 +567,39 @@ func transformMiddlewareFunc(fs ...MiddlewareFunc) middleware.Collection {
 	return m
 }
 
-func newPathSlashesStripper(stripSlashes int) func(r *http.Request) *http.Request {
-	return func(r *http.Request) *http.Request {
-		r2 := new(http.Request)
-		*r2 = *r
-		r2.URL = new(url.URL)
-		*r2.URL = *r.URL
+// newMountRewrite provides function rewriting request passed to router mounted under path,
+// leading path parts are stripped and mount pattern is stored to prefix patterns matched by the router
+func newMountRewrite(path string) func(r *http.Request) *http.Request {
+	stripSlashes := strings.Count(path, "/")
 
+	return func(r *http.Request) *http.Request {
 		p := pathutils.StripLeadingSlashes(r.URL.Path, stripSlashes)
-		if p != "" {
-			r2.URL.Path = p
-		} else {
-			r2.URL.Path = "/"
+		if p == "" {
+			p = "/"
 		}
 
+		prefix, _ := context.Prefix(r.Context())
+		r2 := r.WithContext(context.WithPrefix(r.Context(), prefixPattern(prefix, path)))
+		setPath(r2, p)
+
 		return r2
 	}
 }
+
+// withPath returns shallow copy of the request with URL path replaced
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	setPath(r2, path)
+
+	return r2
+}
+
+// setPath replaces URL path of request copy, URL is copied so the original request keeps it
+func setPath(r *http.Request, path string) {
+	u := new(url.URL)
+	*u = *r.URL
+	u.Path = path
+	u.RawPath = ""
+	r.URL = u
+}
@@ -1,14 +1,17 @@
 package gorouter
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/mux"
 )
 
 func TestInterface(t *testing.T) {
@@ -84,6 +87,7 @@ func TestMethods(t *testing.T) {
 		http.MethodTrace,
 		http.MethodOptions,
 	} {
+		method := method
 		t.Run(method, func(t *testing.T) {
 			t.Parallel()
 
@@ -780,3 +784,1188 @@ func TestMountSubRouter_third(t *testing.T) {
 		t.Errorf("subrouter route did not match: %s", w.Body.String())
 	}
 }
+
+func TestBacktrackingSiblingBranches(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/users/new", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = fmt.Fprint(w, "GET[/users/new]") }))
+	router.GET("/{org}/repos", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprintf(w, "GET[/%s/repos]", params.Value("org"))
+	}))
+	router.USE(http.MethodGet, "/users", mockMiddleware("USE[/users]"))
+
+	for _, compile := range []bool{false, true} {
+		if compile {
+			router.Compile()
+		}
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/users/repos", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != "GET[/users/repos]" {
+			t.Errorf("route did not fall back to sibling branch (compiled: %t): %s", compile, w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest(http.MethodGet, "/users/new", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != "USE[/users]GET[/users/new]" {
+			t.Errorf("static route did not match (compiled: %t): %s", compile, w.Body.String())
+		}
+	}
+}
+
+func TestCatchAllParam(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/files/{filepath...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, params.Value("filepath"))
+	}))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/files/css/site/main.css", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if w.Body.String() != "css/site/main.css" {
+		t.Errorf("Wrong params value. Expected 'css/site/main.css', actual '%s'", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/files/css/site/main.css", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, actual %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestEmbeddedParams(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/reports/{year}-{month}.{format}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprintf(w, "%s|%s|%s", params.Value("year"), params.Value("month"), params.Value("format"))
+	}))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/reports/2020-01.csv", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if w.Body.String() != "2020|01|csv" {
+		t.Errorf("Wrong params value. Expected '2020|01|csv', actual '%s'", w.Body.String())
+	}
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	subRouter := New().(*router)
+	subRouter.GET("/", handler, WithName("user.index"))
+	subRouter.GET("/{id:[0-9]+}", handler, WithName("user.show"))
+
+	mainRouter := New().(*router)
+	mainRouter.GET("/blog/{postsId}", handler, WithName("post.show"))
+	mainRouter.Mount("/{org}/users", subRouter)
+
+	tests := []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{"post.show", []string{"postsId", "42"}, "/blog/42"},
+		{"user.index", []string{"org", "acme"}, "/acme/users"},
+		{"user.show", []string{"org", "acme", "id", "7"}, "/acme/users/7"},
+	}
+	for _, tt := range tests {
+		got, err := mainRouter.URL(tt.name, tt.params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("URL(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := mainRouter.URL("user.show", "org", "acme", "id", "x"); err == nil {
+		t.Error("URL should fail for parameter not matching regexp")
+	}
+
+	if _, err := mainRouter.URL("unknown"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("URL should fail with ErrRouteNotFound, got: %v", err)
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "default")
+	}))
+	router.GET("api.example.com/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "api")
+	}))
+	router.GET("{tenant}.example.com/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprintf(w, "%s:%s", params.Value("tenant"), params.Value("id"))
+	}))
+	router.POST("{tenant}.example.com/users", &mockHandler{})
+
+	tests := []struct {
+		method string
+		url    string
+		code   int
+		body   string
+		allow  string
+	}{
+		{http.MethodGet, "http://localhost/users/1", http.StatusOK, "default", ""},
+		{http.MethodGet, "http://api.example.com/users/1", http.StatusOK, "api", ""},
+		{http.MethodGet, "http://acme.example.com:8080/users/1", http.StatusOK, "acme:1", ""},
+		{http.MethodGet, "http://acme.example.com/users", http.StatusMethodNotAllowed, "", "POST, OPTIONS"},
+		{http.MethodPost, "http://localhost/users", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s %s: expected status %d, actual %d", tt.method, tt.url, tt.code, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body %s, actual %s", tt.method, tt.url, tt.body, w.Body.String())
+		}
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: expected Allow %q, actual %q", tt.method, tt.url, tt.allow, allow)
+		}
+	}
+}
+
+func TestRouteConstraints(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.POST("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "json")
+	}), WithContentType("application/json"))
+	router.POST("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "form")
+	}), WithContentType("application/x-www-form-urlencoded"))
+	router.GET("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "csv")
+	}), WithQuery("format", "csv"))
+	router.GET("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "v2")
+	}), WithHeader("X-API-Version", "2"))
+
+	tests := []struct {
+		method string
+		url    string
+		header string
+		value  string
+		code   int
+		body   string
+	}{
+		{http.MethodPost, "/users", "Content-Type", "application/json", http.StatusOK, "json"},
+		{http.MethodPost, "/users", "Content-Type", "application/x-www-form-urlencoded", http.StatusOK, "form"},
+		{http.MethodPost, "/users", "Content-Type", "text/plain", http.StatusUnsupportedMediaType, ""},
+		{http.MethodGet, "/users?format=csv", "", "", http.StatusOK, "csv"},
+		{http.MethodGet, "/users", "X-API-Version", "2", http.StatusOK, "v2"},
+		{http.MethodGet, "/users", "X-API-Version", "1", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s %s %s: expected status %d, actual %d", tt.method, tt.url, tt.value, tt.code, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s %s %s: expected body %s, actual %s", tt.method, tt.url, tt.value, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestPathPolicy(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	tests := []struct {
+		name     string
+		policy   PathPolicy
+		method   string
+		url      string
+		code     int
+		location string
+		body     string
+	}{
+		{"lenient", PathPolicy{}, http.MethodGet, "/users/", http.StatusOK, "", "/users/"},
+		{"clean rewrite", PathPolicy{CleanPath: true}, http.MethodGet, "/posts//../users", http.StatusOK, "", "/users"},
+		{"clean redirect", PathPolicy{CleanPath: true, RedirectCode: http.StatusMovedPermanently}, http.MethodGet, "/users/./?page=2", http.StatusMovedPermanently, "/users/?page=2", ""},
+		{"clean post rewrite", PathPolicy{CleanPath: true, RedirectCode: http.StatusMovedPermanently}, http.MethodPost, "/posts/..//users", http.StatusOK, "", "/users"},
+		{"strict", PathPolicy{TrailingSlash: TrailingSlashStrict}, http.MethodGet, "/users/", http.StatusNotFound, "", ""},
+		{"strict match", PathPolicy{TrailingSlash: TrailingSlashStrict}, http.MethodGet, "/dirs/", http.StatusOK, "", "/dirs/"},
+		{"strict redirect remove", PathPolicy{TrailingSlash: TrailingSlashStrict, RedirectCode: http.StatusPermanentRedirect}, http.MethodGet, "/users/", http.StatusPermanentRedirect, "/users", ""},
+		{"strict redirect add", PathPolicy{TrailingSlash: TrailingSlashStrict, RedirectCode: http.StatusPermanentRedirect}, http.MethodHead, "/dirs", http.StatusPermanentRedirect, "/dirs/", ""},
+		{"strict catch-all", PathPolicy{TrailingSlash: TrailingSlashStrict}, http.MethodGet, "/files/a/", http.StatusOK, "", "/files/a/"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+			router.PathPolicy(tt.policy)
+			router.GET("/users", handler)
+			router.POST("/users", handler)
+			router.GET("/dirs/", handler)
+			router.HEAD("/dirs/", handler)
+			router.GET("/files/{path...}", handler)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, actual %d", tt.code, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("expected location %q, actual %q", tt.location, location)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected body %q, actual %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPathPolicyIgnoreCase(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, params.Value("id"))
+	})
+
+	router := New()
+	router.PathPolicy(PathPolicy{IgnoreCase: true})
+	router.GET("/blog/posts/{id}", handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/Blog/POSTS/AbC", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "AbC" {
+		t.Errorf("expected status 200 with parameter in original case, actual %d %q", w.Code, w.Body.String())
+	}
+
+	router.PathPolicy(PathPolicy{IgnoreCase: true, RedirectCode: http.StatusMovedPermanently})
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/Blog/POSTS/AbC/?x=1", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/blog/posts/AbC/?x=1" {
+		t.Errorf("expected redirect to registered case, actual %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/blog/posts/AbC", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for registered case, actual %d", w.Code)
+	}
+
+	router.PathPolicy(PathPolicy{})
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/Blog/POSTS/AbC", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 once case is no longer ignored, actual %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/blog/posts/AbC", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for registered case, actual %d", w.Code)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	sub := New()
+	sub.GET("/{id:int}", handler, WithName("item"))
+
+	router := New()
+	router.USE(http.MethodGet, "/api", mockMiddleware("m1"))
+	router.GET("/", handler)
+	router.GET("/api/v1/users", handler)
+	router.GET("/api/v1/users", handler, WithHeader("X-API-Version", "2"))
+	router.POST("/api/{org}/repos/{path...}", handler)
+	router.GET("{tenant}.example.com/dashboard", handler)
+	router.Mount("/items", sub)
+	router.Compile()
+
+	routes := router.Routes()
+
+	var got []string
+	for _, route := range routes {
+		got = append(got, fmt.Sprintf("%s %s%s %v %v %d %t", route.Method, route.Host, route.Pattern, route.Params, route.Constraints, len(route.Middleware), route.Mounted))
+	}
+
+	want := []string{
+		"GET / [] [] 0 false",
+		"GET /api/v1/users [] [] 1 false",
+		"GET /api/v1/users [] [header X-Api-Version=2] 1 false",
+		"GET /items/{id:int} [id:int] [] 0 false",
+		"POST /api/{org}/repos/{path...} [org path...] [] 0 false",
+		"GET {tenant}.example.com/dashboard [tenant] [] 0 false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var count int
+	err := router.Walk(func(route RouteInfo) error {
+		count++
+		return SkipAll
+	})
+	if err != nil || count != 1 {
+		t.Errorf("Walk() should stop on SkipAll, visited %d routes, error %v", count, err)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := New()
+	router.GET("/users/{id}", handler)
+	router.GET("/posts/{id:int}", handler)
+	router.GET("/files/{path...}", handler)
+	router.GET("/named", handler, WithName("named"))
+	router.GET("/accept", handler, WithContentType("application/json"))
+	router.GET("/versioned", handler, WithHeader("X-API-Version", "2"), WithQuery("v", "2"))
+	router.Mount("/sub", handler)
+
+	tests := []struct {
+		pattern string
+		opts    []RouteOption
+		err     error
+	}{
+		{"/users/{id}", nil, ErrDuplicateRoute},
+		{"/users/{name}", nil, mux.ErrConflict},
+		{"/posts/{num:int}", nil, mux.ErrConflict},
+		{"/files/{rest*}", nil, mux.ErrConflict},
+		{"/users/id", nil, mux.ErrConflict},
+		{"/posts/{id:[a-z]+}", nil, nil},
+		{"/posts/{id:int}/x", nil, nil},
+		{"/sub/x", nil, mux.ErrConflict},
+		{"/sub", nil, ErrDuplicateRoute},
+		{"/{org}/{org}", nil, mux.ErrConflict},
+		{"/users/{id:[a-z}", nil, mux.ErrMalformedPattern},
+		{"/users/{id:(}", nil, mux.ErrMalformedPattern},
+		{"/users/{}", nil, mux.ErrMalformedPattern},
+		{"/users/{id:}", nil, mux.ErrMalformedPattern},
+		{"/files/{path...}/x", nil, mux.ErrMalformedPattern},
+		{"/img/{a}{b}.png", nil, mux.ErrMalformedPattern},
+		{"{tenant.example.com/", nil, mux.ErrMalformedPattern},
+		{"/other", []RouteOption{WithName("named")}, ErrDuplicateRoute},
+		{"/accept", []RouteOption{WithContentType("application/json")}, ErrDuplicateRoute},
+		{"/accept", []RouteOption{WithContentType("text/plain")}, nil},
+		{"/accept", []RouteOption{WithContentType("text/plain")}, ErrDuplicateRoute},
+		{"/versioned", []RouteOption{WithQuery("v", "2"), WithHeader("x-api-version", "2")}, ErrDuplicateRoute},
+		{"/versioned", []RouteOption{WithHeader("X-API-Version", "2")}, nil},
+	}
+	for _, tt := range tests {
+		err := router.Register(http.MethodGet, tt.pattern, handler, tt.opts...)
+
+		if tt.err == nil && err != nil {
+			t.Errorf("Register(%q) unexpected error: %v", tt.pattern, err)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Register(%q) error = %v, want %v", tt.pattern, err, tt.err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tt.pattern) {
+			t.Errorf("Register(%q) error should contain pattern: %v", tt.pattern, err)
+		}
+	}
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("Handle should panic for duplicate route")
+		}
+	}()
+
+	router.GET("/users/{id}", handler)
+}
+
+func TestParameterExpressions(t *testing.T) {
+	t.Parallel()
+
+	router := New()
+	router.GET("/x/{id:int}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "int")
+	}))
+	router.GET("/x/{id:[a-z]+}/y", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, "y"+params.Value("id"))
+	}))
+
+	for path, want := range map[string]string{
+		"/x/12":   "int",
+		"/x/ab/y": "yab",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Body.String() != want {
+			t.Errorf("%s: expected %q, got %d %q", path, want, w.Code, w.Body.String())
+		}
+	}
+
+	var got []string
+	for _, route := range router.Routes() {
+		got = append(got, route.Pattern)
+	}
+	if want := []string{"/x/{id:int}", "/x/{id:[a-z]+}/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() = %v, want %v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := New()
+	router.GET("/users/{id:[0-9]+}", handler)
+	router.GET("/users/{name:[a-z]+}", handler)
+
+	if err := router.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+
+	router.GET("/users/{num:\\d+}", handler)
+	router.USE(http.MethodGet, "/admin", mockMiddleware("m"))
+
+	err := router.Validate()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	kinds := make(map[mux.IssueKind]bool)
+	for _, issue := range validationErr.Issues {
+		kinds[issue.Kind] = true
+	}
+
+	if !kinds[mux.ShadowedRoute] || !kinds[mux.OrphanMiddleware] || len(router.Lint()) != len(validationErr.Issues) {
+		t.Errorf("Unexpected issues: %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := New()
+	router.GET("/users/{id}", handler, WithName("user"))
+	router.POST("/users/{id}", handler)
+	router.GET("{tenant}.example.com/users", handler)
+
+	if router.Remove(http.MethodGet, "/users/{name}") {
+		t.Error("Route should be removed only under the pattern it was registered with")
+	}
+
+	if !router.Remove(http.MethodGet, "/users/{id}") {
+		t.Fatal("Expected route to be removed")
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for removed route, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if _, err := router.URL("user", "id", "1"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Name of removed route should be released, got %v", err)
+	}
+
+	if router.Remove(http.MethodGet, "acme.example.com/users") || !router.Remove(http.MethodGet, "{tenant}.example.com/users") {
+		t.Error("Host route should be removed under its host pattern")
+	}
+	if len(router.Routes()) != 1 {
+		t.Errorf("Expected single route left, got %v", router.Routes())
+	}
+
+	router.GET("/files/{p*}", handler)
+	router.GET("/docs/{p...}", handler)
+
+	if !router.Remove(http.MethodGet, "/files/{p*}") || !router.Remove(http.MethodGet, "/docs/{p*}") {
+		t.Fatal("Expected catch-all route to be removed under either spelling")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a/b", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for removed catch-all route, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+
+	router := New()
+	router.GET("/users/{id}", ok)
+	// mounted handler adds Node of every method, so method Nodes are indexed
+	router.Mount("/static", ok)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			pattern := fmt.Sprintf("/tenants/t%d/{id}", i)
+			router.GET(pattern, ok)
+			router.USE(http.MethodGet, pattern, mockMiddleware("m"))
+			if i%2 == 0 {
+				router.Remove(http.MethodGet, pattern)
+			}
+		}
+		router.Compile()
+	}()
+
+	for {
+		select {
+		case <-done:
+			if len(router.Routes()) != 60 {
+				t.Errorf("Expected 60 routes, got %d", len(router.Routes()))
+			}
+			return
+		default:
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unexpected status %d", w.Code)
+		}
+
+		// tenants are matched while they are registered
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tenants/t1/1", nil))
+	}
+}
+
+func TestServeWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+	router.GET("/users/{id}", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+
+	// changes are published by registration, requests do not take the lock
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	served := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		served <- w.Code
+	}()
+
+	select {
+	case code := <-served:
+		if code != http.StatusOK {
+			t.Errorf("Unexpected status %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Request waited for the registration lock")
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	t.Parallel()
+
+	router := New()
+	router.GET("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte("h")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	router.USE(http.MethodGet, "/users/{id}", mockMiddleware("2"))
+	router.USE(http.MethodGet, "/", mockMiddleware("1"))
+
+	serve := func() string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		return w.Body.String()
+	}
+
+	if got := serve(); got != "21h" {
+		t.Errorf("Expected middleware in registration order, got %q", got)
+	}
+
+	router.USE(http.MethodGet, "/users", mockMiddleware("3"))
+
+	if got := serve(); got != "213h" {
+		t.Errorf("Expected chain to include middleware added after serving, got %q", got)
+	}
+}
+
+func TestMiddlewareChainAllocs(t *testing.T) {
+	next := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	router := New()
+	router.GET("/users/list", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	router.USE(http.MethodGet, "/", next)
+	router.USE(http.MethodGet, "/users", next, next)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/list", nil)
+
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) }); allocs != 0 {
+		t.Errorf("Expected no allocations for route with middleware, got %v", allocs)
+	}
+}
+
+func TestOrphanMiddlewareBranch(t *testing.T) {
+	next := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	router := New()
+	router.GET("/users/list", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	router.GET("/x/{param}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	router.USE(http.MethodGet, "/users", next)
+	router.USE(http.MethodGet, "/x/x", mockMiddleware("m1"))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/list", nil)
+
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) }); allocs != 0 {
+		t.Errorf("Expected no allocations for route outside of orphan middleware branch, got %v", allocs)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x/x", nil))
+
+	if w.Body.String() != "m1x" {
+		t.Errorf("Use middleware error %s", w.Body.String())
+	}
+}
+
+func TestChangedRoutesMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte("h")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// routes are composed with middleware as the Tree changes
+	router := New()
+	router.USE(http.MethodGet, "/a", mockMiddleware("m1"))
+	router.GET("/a/b", handler)
+	router.GET("/a/c", handler)
+	router.USE(http.MethodGet, "/a/c", mockMiddleware("m2"))
+	router.GET("/a/c/d", handler)
+	router.USE(http.MethodGet, "/x/y", mockMiddleware("m3"))
+	router.GET("/{p}/y", handler)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/a/b", "m1h"},
+		{"/a/c", "m1m2h"},
+		{"/a/c/d", "m1m2h"},
+		{"/x/y", "m3h"},
+		{"/z/y", "h"},
+	}
+	check := func(name string) {
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Body.String() != tt.body {
+				t.Errorf("%s: %s body = %q, want %q", name, tt.path, w.Body.String(), tt.body)
+			}
+		}
+	}
+
+	check("registered")
+
+	router.Remove(http.MethodGet, "/a/b")
+	router.Remove(http.MethodGet, "/a/c/d")
+	router.Remove(http.MethodGet, "/a/c")
+	tests = tests[3:]
+
+	// branch left without routes holds orphan middleware
+	router.GET("/{p}/c", handler)
+	router.USE(http.MethodGet, "/n", mockMiddleware("m4"))
+	router.Mount("/n/m", handler)
+	tests = append(tests, []struct {
+		path string
+		body string
+	}{
+		{"/a/c", "m1m2h"},
+		{"/n/m/x", "m4h"},
+	}...)
+
+	check("removed")
+}
+
+func TestPatternAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("pooled values are dropped randomly with race detector")
+	}
+
+	var pattern string
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		pattern, _ = context.Pattern(req.Context())
+	})
+
+	router := New()
+	router.GET("/users/list", handler)
+	router.GET("/users/{id}", handler)
+
+	w := httptest.NewRecorder()
+	static := httptest.NewRequest(http.MethodGet, "/users/list", nil)
+	params := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, static) }); allocs != 0 {
+		t.Errorf("Expected no allocations for static route, got %v", allocs)
+	}
+
+	// pattern is stored along with params in pooled request copy and context
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, params) }); allocs != 0 {
+		t.Errorf("Expected no allocations for route with params, got %v", allocs)
+	}
+	if pattern != "/users/{id}" {
+		t.Errorf("Expected pattern stored along with params, got %q", pattern)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, static) }); allocs != 0 {
+		t.Errorf("Expected no allocations for static route, got %v", allocs)
+	}
+	if pattern != "/users/list" {
+		t.Errorf("Expected pattern of static route, got %q", pattern)
+	}
+
+	// pattern is held for global middleware in pooled request copy and context
+	global := New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+		})
+	})
+	global.GET("/users/list", handler)
+
+	if allocs := testing.AllocsPerRun(100, func() { global.ServeHTTP(w, static) }); allocs != 0 {
+		t.Errorf("Expected no allocations for route of router with global middleware, got %v", allocs)
+	}
+}
+
+func TestCompileStaticRoutes(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	router := New().(*router)
+	router.GET("/healthz", write("healthz"))
+	router.GET("/api/v1/status", write("status"))
+	router.GET("/api/v1/{name}", write("name"))
+	router.USE(http.MethodGet, "/api", mockMiddleware("m1"))
+	router.Compile()
+	router.GET("/ready", write("ready"))
+
+	statics := router.routing().statics[http.MethodGet]
+	for _, path := range []string{"healthz", "api/v1/status", "ready"} {
+		if _, ok := statics[path]; !ok {
+			t.Errorf("Expected static route for %s, got %v", path, statics)
+		}
+	}
+	if len(statics) != 3 {
+		t.Errorf("Expected only fully static routes, got %v", statics)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/healthz", "healthz"},
+		{"/api/v1/status", "m1status"},
+		{"/api/v1/users", "m1name"},
+		{"/ready", "ready"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Body.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestRouteMetadata(t *testing.T) {
+	t.Parallel()
+
+	var got *context.RouteMetadata
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		got, _ = context.Metadata(req.Context())
+	})
+
+	router := New()
+	router.GET("/users/{id}", handler,
+		WithName("user"),
+		WithTags("users", "public"),
+		WithDescription("Shows user"),
+		WithScopes("users:read"),
+		WithTimeout(time.Second),
+		WithBodyLimit(1024),
+		WithMetadata("owner", "team-a"),
+	)
+	router.GET("/health", handler)
+
+	want := context.RouteMetadata{
+		Name:        "user",
+		Tags:        []string{"users", "public"},
+		Description: "Shows user",
+		Scopes:      []string{"users:read"},
+		Timeout:     time.Second,
+		BodyLimit:   1024,
+		Values:      map[string]interface{}{"owner": "team-a"},
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("Metadata() = %+v, want %+v", got, want)
+	}
+	if v, _ := got.Value("owner"); v != "team-a" {
+		t.Errorf("Value(owner) = %v, want team-a", v)
+	}
+
+	got = nil
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if got != nil {
+		t.Errorf("Expected no metadata for route registered without options, got %+v", got)
+	}
+
+	routes := router.Routes()
+	if len(routes) != 2 || routes[0].Name != "user" || !reflect.DeepEqual(routes[0].Metadata, want) {
+		t.Errorf("Routes() = %+v, want metadata %+v", routes, want)
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	var global, route, handler string
+	record := func(pattern *string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req)
+				// global middleware read pattern once request is routed
+				*pattern, _ = context.Pattern(req.Context())
+			})
+		}
+	}
+	write := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		handler, _ = context.Pattern(req.Context())
+	})
+
+	sub := New()
+	sub.GET("/{id}", write)
+	sub.GET("/", write)
+
+	nested := New(record(new(string)))
+	nested.GET("/{file...}", write)
+	sub.Mount("/{id}/files", nested)
+
+	router := New(record(&global))
+	router.USE(http.MethodGet, "/users", record(&route))
+	router.GET("/users/{id:[0-9]+}", write)
+	router.GET("/", write)
+	router.Mount("/items", sub)
+
+	tests := []struct {
+		path            string
+		want            string
+		routeMiddleware bool
+	}{
+		{"/users/1", "/users/{id:[0-9]+}", true},
+		{"/", "/", false},
+		{"/items/1", "/items/{id}", false},
+		{"/items/", "/items/", false},
+		{"/items/1/files/a/b", "/items/{id}/files/{file...}", false},
+		{"/missing", "", false},
+	}
+	for _, tt := range tests {
+		global, route, handler = "", "", ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if global != tt.want {
+			t.Errorf("%s: global middleware pattern = %q, want %q", tt.path, global, tt.want)
+		}
+		if tt.want != "" && handler != tt.want {
+			t.Errorf("%s: handler pattern = %q, want %q", tt.path, handler, tt.want)
+		}
+		if tt.routeMiddleware && route != tt.want {
+			t.Errorf("%s: route middleware pattern = %q, want %q", tt.path, route, tt.want)
+		}
+	}
+
+}
+
+func TestRoutePatternDefault(t *testing.T) {
+	t.Parallel()
+
+	var global, handler string
+	router := New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+			global, _ = context.Pattern(req.Context())
+		})
+	})
+	router.GET("/healthz", http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		handler, _ = context.Pattern(req.Context())
+	}))
+	router.GET("/users/{id}", http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		handler, _ = context.Pattern(req.Context())
+	}))
+
+	for path, want := range map[string]string{"/healthz": "/healthz", "/users/1": "/users/{id}"} {
+		global, handler = "", ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		if handler != want || global != want {
+			t.Errorf("%s: pattern = %q in global middleware, %q in handler, want %q", path, global, handler, want)
+		}
+	}
+
+	plain := New()
+	plain.GET("/healthz", http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		if pattern, ok := context.Pattern(req.Context()); !ok || pattern != "/healthz" {
+			t.Errorf("Expected pattern of static route, got %q", pattern)
+		}
+	}))
+	plain.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+
+	router := New()
+	router.USE(http.MethodPost, "/users", mockMiddleware("1"))
+	router.POST("/users", handler, WithMiddleware(mockMiddleware("a"), mockMiddleware("b")))
+	router.POST("/users", handler, WithHeader("X-Admin", ""), WithMiddleware(mockMiddleware("x")))
+	router.POST("/users/{id}/avatar", handler)
+	router.USE(http.MethodPost, "/", mockMiddleware("2"))
+
+	tests := []struct {
+		path   string
+		header string
+		want   string
+	}{
+		{"/users", "", "12ab"},
+		{"/users", "X-Admin", "12x"},
+		{"/users/1/avatar", "", "12"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, "1")
+		}
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.want {
+			t.Errorf("%s %s: expected middleware %q, got %q", tt.path, tt.header, tt.want, w.Body.String())
+		}
+	}
+
+	if routes := router.Routes(); len(routes[0].Middleware) != 4 {
+		t.Errorf("Expected branch and route middleware in Routes(), got %d", len(routes[0].Middleware))
+	}
+
+	err := router.Register(http.MethodGet, "/", handler, WithFastHTTPMiddleware(mockFastHTTPMiddleware("1")))
+	if !errors.Is(err, ErrMiddlewareType) {
+		t.Errorf("Register() error = %v, want ErrMiddlewareType", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	router := New()
+	router.Group("/api/v2", func(api Router) {
+		api.GET("/status", write("s"))
+		api.Group("/admin", func(admin Router) {
+			admin.GET("/users/{id}", write("u"), WithMiddleware(mockMiddleware("r")))
+			admin.POST("/users", write("c"))
+			admin.USE(http.MethodPost, "/users", mockMiddleware("b"))
+		}, mockMiddleware("2"))
+	}, mockMiddleware("1"))
+	router.GET("/api/v2/admin/stats", write("x"))
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/api/v2/status", "1s"},
+		{http.MethodGet, "/api/v2/admin/users/1", "12ru"},
+		{http.MethodPost, "/api/v2/admin/users", "b12c"},
+		{http.MethodGet, "/api/v2/admin/stats", "x"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if w.Body.String() != tt.want {
+			t.Errorf("%s %s: expected %q, got %q", tt.method, tt.path, tt.want, w.Body.String())
+		}
+	}
+
+	var got []string
+	for _, route := range router.Routes() {
+		got = append(got, route.Method+" "+route.Pattern)
+	}
+	want := []string{
+		"GET /api/v2/admin/users/{id}",
+		"GET /api/v2/admin/stats",
+		"GET /api/v2/status",
+		"POST /api/v2/admin/users",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupMount(t *testing.T) {
+	t.Parallel()
+
+	sub := New()
+	sub.GET("/ping/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, "p"+params.Value("id"))
+	}), WithName("ping"))
+
+	router := New()
+	router.Group("/api", func(api Router) {
+		api.Group("/admin", func(admin Router) {
+			admin.Mount("/sub", sub)
+		}, mockMiddleware("2"))
+	}, mockMiddleware("1"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/sub/ping/7", nil))
+
+	if w.Body.String() != "12p7" {
+		t.Errorf("expected mounted router to run group middleware first, got %q", w.Body.String())
+	}
+
+	if url, err := router.URL("ping", "id", "7"); err != nil || url != "/api/admin/sub/ping/7" {
+		t.Errorf("URL() = %q, %v, want /api/admin/sub/ping/7", url, err)
+	}
+
+	routes := router.Routes()
+	if len(routes) != 1 || routes[0].Pattern != "/api/admin/sub/ping/{id}" || len(routes[0].Middleware) != 2 {
+		t.Errorf("Routes() = %+v, want mounted route with group middleware", routes)
+	}
+}
@@ -0,0 +1,230 @@
+package path
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SegmentKind is a type of pattern Segment
+type SegmentKind uint8
+
+const (
+	// StaticSegment is a static text, e.g. users
+	StaticSegment SegmentKind = iota
+	// ParamSegment is a named parameter, e.g. {id}
+	ParamSegment
+	// RegexpSegment is a parameter with expression, regexp or matcher name, e.g. {id:[0-9]+}
+	RegexpSegment
+	// CatchAllSegment is a parameter matching the rest of the path, e.g. {path...} or {path*}
+	CatchAllSegment
+)
+
+// Segment is a static text or parameter of path Part
+type Segment struct {
+	Kind SegmentKind
+	// Raw is a pattern text of Segment, e.g. {id:[0-9]+}
+	Raw string
+	// Name of the parameter, static text for StaticSegment
+	Name string
+	// Exp is a parameter expression of RegexpSegment
+	Exp string
+	// Offset is a byte offset of Segment within parsed pattern
+	Offset int
+}
+
+// Part is a path part between slashes
+// consisting of single Segment or static text mixed with parameters, e.g. {year}-{month}.{format}
+type Part struct {
+	Raw      string
+	Offset   int
+	Segments []Segment
+}
+
+// IsStatic checks if Part contains static text only
+func (p Part) IsStatic() bool {
+	return len(p.Segments) == 1 && p.Segments[0].Kind == StaticSegment
+}
+
+// SyntaxError describes pattern that can not be parsed
+type SyntaxError struct {
+	Pattern string
+	// Offset is a byte offset of the error within Pattern
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d in %q", e.Msg, e.Offset, e.Pattern)
+}
+
+// Parse parses route pattern into path parts, leading and trailing slashes are skipped
+// e.g. /users/{id:[0-9]+}/{path...} is parsed into three parts
+func Parse(pattern string) ([]Part, error) {
+	start, end := 0, len(pattern)
+	if end > 0 && pattern[0] == '/' {
+		start++
+	}
+	if end > start && pattern[end-1] == '/' {
+		end--
+	}
+	if start >= end {
+		return nil, nil
+	}
+
+	var parts []Part
+	var depth, openAt int
+	partStart := start
+
+	// slash within braces belongs to parameter expression, e.g. {id:[^/]+}
+	for i := start; i <= end; i++ {
+		if i < end {
+			switch pattern[i] {
+			case '{':
+				if depth == 0 {
+					openAt = i
+				}
+				depth++
+				continue
+			case '}':
+				depth--
+				if depth < 0 {
+					return nil, &SyntaxError{pattern, i, "unexpected }"}
+				}
+				continue
+			case '/':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		} else if depth > 0 {
+			return nil, &SyntaxError{pattern, openAt, "unclosed {"}
+		}
+
+		part, err := parsePart(pattern, partStart, i)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, part)
+		partStart = i + 1
+	}
+
+	for i, part := range parts[:len(parts)-1] {
+		if part.Segments[0].Kind == CatchAllSegment {
+			return nil, &SyntaxError{pattern, parts[i].Offset, "catch-all parameter has to be the last path part"}
+		}
+	}
+
+	return parts, nil
+}
+
+// ParsePart parses single path part, e.g. {year}-{month}.{format}
+func ParsePart(part string) (Part, error) {
+	return parsePart(part, 0, len(part))
+}
+
+func parsePart(pattern string, start, end int) (Part, error) {
+	part := Part{Raw: pattern[start:end], Offset: start}
+	if start == end {
+		return part, &SyntaxError{pattern, start, "empty path part"}
+	}
+
+	var depth int
+	chunkStart := start
+
+	for i := start; i < end; i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 && i > chunkStart {
+				part.Segments = append(part.Segments, Segment{
+					Kind:   StaticSegment,
+					Raw:    pattern[chunkStart:i],
+					Name:   pattern[chunkStart:i],
+					Offset: chunkStart,
+				})
+				chunkStart = i
+			}
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return part, &SyntaxError{pattern, i, "unexpected }"}
+			}
+			if depth == 0 {
+				segment, err := parseParam(pattern, chunkStart, i+1)
+				if err != nil {
+					return part, err
+				}
+
+				if n := len(part.Segments); n > 0 && part.Segments[n-1].Kind != StaticSegment {
+					return part, &SyntaxError{pattern, chunkStart, "parameters have to be separated by static text"}
+				}
+
+				part.Segments = append(part.Segments, segment)
+				chunkStart = i + 1
+			}
+		case '/':
+			if depth == 0 {
+				return part, &SyntaxError{pattern, i, "path part can not contain /"}
+			}
+		}
+	}
+
+	if depth > 0 {
+		return part, &SyntaxError{pattern, chunkStart, "unclosed {"}
+	}
+
+	if chunkStart < end {
+		part.Segments = append(part.Segments, Segment{
+			Kind:   StaticSegment,
+			Raw:    pattern[chunkStart:end],
+			Name:   pattern[chunkStart:end],
+			Offset: chunkStart,
+		})
+	}
+
+	if len(part.Segments) > 1 {
+		for _, segment := range part.Segments {
+			if segment.Kind == CatchAllSegment {
+				return part, &SyntaxError{pattern, segment.Offset, "catch-all parameter can not be mixed with static text"}
+			}
+		}
+	}
+
+	return part, nil
+}
+
+func parseParam(pattern string, start, end int) (Segment, error) {
+	segment := Segment{
+		Kind:   ParamSegment,
+		Raw:    pattern[start:end],
+		Offset: start,
+	}
+
+	body := pattern[start+1 : end-1]
+	if i := strings.IndexByte(body, ':'); i >= 0 {
+		segment.Kind = RegexpSegment
+		segment.Name = body[:i]
+		segment.Exp = body[i+1:]
+
+		if segment.Exp == "" {
+			return segment, &SyntaxError{pattern, start + i + 2, "empty parameter expression"}
+		}
+	} else if strings.HasSuffix(body, "...") || strings.HasSuffix(body, "*") {
+		segment.Kind = CatchAllSegment
+		segment.Name = strings.TrimSuffix(strings.TrimSuffix(body, "..."), "*")
+	} else {
+		segment.Name = body
+	}
+
+	if segment.Name == "" {
+		return segment, &SyntaxError{pattern, start + 1, "empty parameter name"}
+	}
+	if i := strings.IndexAny(segment.Name, "{}"); i >= 0 {
+		return segment, &SyntaxError{pattern, start + 1 + i, "invalid parameter name"}
+	}
+
+	return segment, nil
+}
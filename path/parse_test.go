@@ -0,0 +1,81 @@
+package path
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []Part
+	}{
+		{"/", nil},
+		{"/users/", []Part{
+			{Raw: "users", Offset: 1, Segments: []Segment{{StaticSegment, "users", "users", "", 1}}},
+		}},
+		{"/users/{id:[0-9]+}/{path...}", []Part{
+			{Raw: "users", Offset: 1, Segments: []Segment{{StaticSegment, "users", "users", "", 1}}},
+			{Raw: "{id:[0-9]+}", Offset: 7, Segments: []Segment{{RegexpSegment, "{id:[0-9]+}", "id", "[0-9]+", 7}}},
+			{Raw: "{path...}", Offset: 19, Segments: []Segment{{CatchAllSegment, "{path...}", "path", "", 19}}},
+		}},
+		{"/{page:[^/]+}/{a:b:c}", []Part{
+			{Raw: "{page:[^/]+}", Offset: 1, Segments: []Segment{{RegexpSegment, "{page:[^/]+}", "page", "[^/]+", 1}}},
+			{Raw: "{a:b:c}", Offset: 14, Segments: []Segment{{RegexpSegment, "{a:b:c}", "a", "b:c", 14}}},
+		}},
+		{`/v{version}/{id:\d{3}}.{format}`, []Part{
+			{Raw: "v{version}", Offset: 1, Segments: []Segment{
+				{StaticSegment, "v", "v", "", 1},
+				{ParamSegment, "{version}", "version", "", 2},
+			}},
+			{Raw: `{id:\d{3}}.{format}`, Offset: 12, Segments: []Segment{
+				{RegexpSegment, `{id:\d{3}}`, "id", `\d{3}`, 12},
+				{StaticSegment, ".", ".", "", 22},
+				{ParamSegment, "{format}", "format", "", 23},
+			}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.pattern, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		offset  int
+		msg     string
+	}{
+		{"/users//posts", 7, "empty path part"},
+		{"/users/{id", 7, "unclosed {"},
+		{"/users/id}", 9, "unexpected }"},
+		{"/users/{}", 8, "empty parameter name"},
+		{"/users/{:[0-9]+}", 8, "empty parameter name"},
+		{"/users/{id:}", 11, "empty parameter expression"},
+		{"/users/{a{b}}", 9, "invalid parameter name"},
+		{"/{year}{month}", 7, "parameters have to be separated by static text"},
+		{"/{path...}/users", 1, "catch-all parameter has to be the last path part"},
+		{"/files/{path*}.zip", 7, "catch-all parameter can not be mixed with static text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := Parse(tt.pattern)
+
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("Parse(%q) error = %v, want *SyntaxError", tt.pattern, err)
+			}
+			if syntaxErr.Offset != tt.offset || syntaxErr.Msg != tt.msg {
+				t.Errorf("Parse(%q) error = %v, want %q at offset %d", tt.pattern, err, tt.msg, tt.offset)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package path
 
-import "strings"
+import (
+	stdpath "path"
+	"strings"
+)
 
 // TrimSlash trims '/' URL path
 func TrimSlash(path string) string {
@@ -17,6 +20,28 @@ func TrimSlash(path string) string {
 	return path
 }
 
+// Clean returns canonical form of URL path
+// removing duplicate slashes and resolving dot-segments, trailing slash is preserved
+func Clean(path string) string {
+	if path == "" {
+		return "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+
+	clean := stdpath.Clean(path)
+	if clean != "/" && path[len(path)-1] == '/' {
+		if clean == path[:len(path)-1] {
+			return path
+		}
+
+		return clean + "/"
+	}
+
+	return clean
+}
+
 // GetPart returns first path part and next path as a second argument
 func GetPart(path string) (part string, nextPath string) {
 	if j := strings.IndexByte(path, '/'); j > 0 {
@@ -31,25 +56,37 @@ func GetPart(path string) (part string, nextPath string) {
 }
 
 // GetNameFromPart gets node name from path part
+// path part mixing static text and parameters is named after the whole part
 func GetNameFromPart(pathPart string) (name string, exp string) {
-	name = pathPart
+	part, err := ParsePart(pathPart)
+	if err != nil {
+		return malformedPartName(pathPart)
+	}
 
-	if pathPart[0] == '{' {
-		name = pathPart[1 : len(pathPart)-1]
+	if len(part.Segments) > 1 {
+		return pathPart, ""
+	}
 
-		if parts := strings.Split(name, ":"); len(parts) == 2 {
-			name = parts[0]
-			exp = parts[1]
-		}
+	return part.Segments[0].Name, part.Segments[0].Exp
+}
 
-		if name == "" {
-			panic("Empty wildcard name")
-		}
+// malformedPartName names path part that can not be parsed by trimming its braces,
+// e.g. unclosed {name:(w+) is named name, part left without name is named after itself
+func malformedPartName(pathPart string) (name string, exp string) {
+	if len(pathPart) < 2 || pathPart[0] != '{' {
+		return pathPart, ""
+	}
 
-		return
+	name = pathPart[1 : len(pathPart)-1]
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name, exp = name[:i], name[i+1:]
 	}
 
-	return
+	if name == "" {
+		return pathPart, ""
+	}
+
+	return name, exp
 }
 
 func StripLeadingSlashes(path string, stripSlashes int) string {
@@ -76,6 +76,13 @@ func TestGetNameFromPart(t *testing.T) {
 		{"x", args{"x"}, "x"},
 		{"{name}", args{"{name}"}, "name"},
 		{"{name:(w+)", args{"{name:(w+)"}, "name"},
+		{"{name...}", args{"{name...}"}, "name"},
+		{"{name*}", args{"{name*}"}, "name"},
+		{"{name}.{ext}", args{"{name}.{ext}"}, "{name}.{ext}"},
+		{"{id:\\d{3}}", args{`{id:\d{3}}`}, "id"},
+		{"{}", args{"{}"}, "{}"},
+		{"{:x}", args{"{:x}"}, "{:x}"},
+		{"empty", args{""}, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -106,3 +113,27 @@ func TestStripLeadingSlashes(t *testing.T) {
 		})
 	}
 }
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"users", "/users"},
+		{"/users/", "/users/"},
+		{"//users//1", "/users/1"},
+		{"/users/./1/", "/users/1/"},
+		{"/users/../posts/1", "/posts/1"},
+		{"/../users", "/users"},
+		{"/users/..", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Clean(tt.path); got != tt.want {
+				t.Errorf("Clean(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
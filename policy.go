@@ -0,0 +1,154 @@
+package gorouter
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// TrailingSlashPolicy defines how trailing slash of the request path is matched
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashLenient matches /users and /users/ with the same route
+	TrailingSlashLenient TrailingSlashPolicy = iota
+	// TrailingSlashStrict matches request path only when its trailing slash
+	// agrees with the route pattern, /users/ does not match /users route
+	TrailingSlashStrict
+)
+
+// PathPolicy defines request path normalization applied before matching
+type PathPolicy struct {
+	// TrailingSlash sets trailing slash matching, lenient by default
+	TrailingSlash TrailingSlashPolicy
+	// CleanPath removes duplicate slashes and resolves dot-segments
+	// e.g. /users//../posts/./1 is matched as /posts/1
+	CleanPath bool
+	// IgnoreCase matches static text of the routes case-insensitively,
+	// parameter values keep their original case
+	IgnoreCase bool
+	// RedirectCode is a status code GET and HEAD requests are redirected with
+	// to canonical path, http.StatusMovedPermanently or http.StatusPermanentRedirect.
+	// When zero, cleaned path is rewritten in place, path not satisfying strict trailing slash
+	// is not found and path in other case than registered is served as it is
+	RedirectCode int
+}
+
+// apply prepares Tree nodes for matching according to policy
+func (p PathPolicy) apply(t mux.Tree) mux.Tree {
+	if p.IgnoreCase {
+		return t.IgnoreCase()
+	}
+
+	return t
+}
+
+// applyPath prepares Nodes on the path, added to Tree already prepared for policy,
+// for matching according to policy
+func (p PathPolicy) applyPath(t mux.Tree, host, path string) mux.Tree {
+	if !p.IgnoreCase {
+		return t
+	}
+
+	if host != "" {
+		hostTree(t, host).IgnoreCasePath(path)
+	} else {
+		t.IgnoreCasePath(path)
+	}
+
+	return t
+}
+
+// reapply prepares Tree nodes, already prepared for previous policy, for matching according to policy
+func (p PathPolicy) reapply(t mux.Tree, previous PathPolicy) mux.Tree {
+	if previous.IgnoreCase && !p.IgnoreCase {
+		t = t.MatchCase()
+	}
+
+	return p.apply(t)
+}
+
+// redirects checks if request with given method should be redirected to canonical path
+func (p PathPolicy) redirects(method string) bool {
+	return p.RedirectCode != 0 && (method == http.MethodGet || method == http.MethodHead)
+}
+
+// cleanPath returns canonical form of the request path when it differs
+func (p PathPolicy) cleanPath(path string) (string, bool) {
+	if !p.CleanPath {
+		return path, false
+	}
+
+	clean := pathutils.Clean(path)
+
+	return clean, clean != path
+}
+
+// trailingSlashPath returns path with trailing slash of the route pattern
+// when strict policy does not match request path
+func (p PathPolicy) trailingSlashPath(r *route, path string) (string, bool) {
+	if p.TrailingSlash != TrailingSlashStrict || r.pattern == "" || path == "/" {
+		return path, false
+	}
+
+	patternSlash := strings.HasSuffix(r.pattern, "/")
+	if patternSlash == strings.HasSuffix(path, "/") {
+		return path, false
+	}
+
+	// catch-all parameter value may end with slash
+	if hasCatchAll(r.pattern) {
+		return path, false
+	}
+
+	if patternSlash {
+		return path + "/", true
+	}
+
+	return strings.TrimSuffix(path, "/"), true
+}
+
+// hasCatchAll checks if pattern ends with catch-all parameter
+func hasCatchAll(pattern string) bool {
+	parts, _ := pathutils.Parse(pattern)
+	if len(parts) == 0 {
+		return false
+	}
+
+	return parts[len(parts)-1].Segments[0].Kind == pathutils.CatchAllSegment
+}
+
+// casePath returns path with static text in the case route pattern was registered with
+// when it differs from request path
+func (p PathPolicy) casePath(r *route, path string, params context.Params) (string, bool) {
+	if !p.IgnoreCase || r.pattern == "" || path == "/" {
+		return path, false
+	}
+
+	pairs := make([]string, 0, 2*len(params))
+	for _, param := range params {
+		pairs = append(pairs, param.Key, param.Value)
+	}
+
+	canonical, err := newURLTemplate(r.pattern).build(pairs...)
+	if err != nil {
+		return path, false
+	}
+	if canonical, err = url.PathUnescape(canonical); err != nil {
+		return path, false
+	}
+
+	if canonical != "/" && strings.HasSuffix(path, "/") {
+		canonical += "/"
+	}
+
+	if canonical == path || !strings.EqualFold(canonical, path) {
+		return path, false
+	}
+
+	return canonical, true
+}
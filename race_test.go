@@ -0,0 +1,7 @@
+//go:build race
+// +build race
+
+package gorouter
+
+// raceEnabled reports tests are run with race detector, which makes sync.Pool drop values randomly
+const raceEnabled = true
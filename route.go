@@ -1,20 +1,304 @@
 package gorouter
 
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
+	"github.com/vardius/gorouter/v4/mux"
+)
+
+// ErrDuplicateRoute is returned when registering route under method and pattern,
+// or with a name, already used by another route
+var ErrDuplicateRoute = errors.New("duplicate route")
+
+// ErrMiddlewareType is returned when registering route with middleware
+// of the other router type, e.g. fasthttp middleware for net/http route
+var ErrMiddlewareType = errors.New("middleware of other router type")
+
+// RouteOption configures route at registration time
+type RouteOption func(r *route)
+
+// WithName names route so its URL can be built with URL method of the router
+func WithName(name string) RouteOption {
+	return func(r *route) {
+		r.metadata().Name = name
+	}
+}
+
+// WithTags tags route, tags are added to the ones set by previous options
+func WithTags(tags ...string) RouteOption {
+	return func(r *route) {
+		m := r.metadata()
+		m.Tags = append(m.Tags, tags...)
+	}
+}
+
+// WithDescription describes route
+func WithDescription(description string) RouteOption {
+	return func(r *route) {
+		r.metadata().Description = description
+	}
+}
+
+// WithScopes sets auth scopes required to access route,
+// scopes are added to the ones set by previous options
+func WithScopes(scopes ...string) RouteOption {
+	return func(r *route) {
+		m := r.metadata()
+		m.Scopes = append(m.Scopes, scopes...)
+	}
+}
+
+// WithTimeout sets timeout for handling route requests, router does not enforce it
+func WithTimeout(timeout time.Duration) RouteOption {
+	return func(r *route) {
+		r.metadata().Timeout = timeout
+	}
+}
+
+// WithBodyLimit sets maximum size of route request body in bytes, router does not enforce it
+func WithBodyLimit(limit int64) RouteOption {
+	return func(r *route) {
+		r.metadata().BodyLimit = limit
+	}
+}
+
+// WithMetadata stores arbitrary value under key in route metadata
+func WithMetadata(key string, value interface{}) RouteOption {
+	return func(r *route) {
+		m := r.metadata()
+		if m.Values == nil {
+			m.Values = make(map[string]interface{})
+		}
+		m.Values[key] = value
+	}
+}
+
+// WithMiddleware attaches middleware to net/http route only,
+// they run after middleware attached to route branch with USE method
+func WithMiddleware(fs ...MiddlewareFunc) RouteOption {
+	return func(r *route) {
+		r.httpMiddleware = append(r.httpMiddleware, fs...)
+	}
+}
+
+// WithFastHTTPMiddleware attaches middleware to fasthttp route only,
+// they run after middleware attached to route branch with USE method
+func WithFastHTTPMiddleware(fs ...FastHTTPMiddlewareFunc) RouteOption {
+	return func(r *route) {
+		r.fastHTTPMiddleware = append(r.fastHTTPMiddleware, fs...)
+	}
+}
+
 type route struct {
 	handler interface{}
+	pattern string
+	// middleware attached to route only, set by router from middleware of its type
+	middleware         middleware.Collection
+	httpMiddleware     []MiddlewareFunc
+	fastHTTPMiddleware []FastHTTPMiddlewareFunc
+	// meta is set by route options, it is nil for routes registered without them
+	// and is not modified once route is registered
+	meta *context.RouteMetadata
+	// subrouter is a handler mounted under mount pattern
+	subrouter interface{}
+	// router is a mounted router listing its routes and building their URL,
+	// nil when mounted handler is not a router
+	router      interface{}
+	mount       string
+	constraints []constraint
+	// alternatives are routes registered under the same method and pattern,
+	// selected by their constraints
+	alternatives []*route
+	// chain is a handler composed with middleware of route branch,
+	// set to route copies of published snapshot
+	chain interface{}
+	// patternValue is a pattern recorded for requests, converted to interface value
+	// once so storing it in fasthttp user values does not allocate
+	patternValue interface{}
+	// orphanMiddleware reports that requests routed to route can match middleware
+	// registered on sibling branch without routes, which chain does not include
+	orphanMiddleware bool
 }
 
-func newRoute(h interface{}) *route {
+func newRoute(h interface{}, opts ...RouteOption) *route {
 	if h == nil {
 		panic("Handler can not be nil.")
 	}
 
-	return &route{
+	r := &route{
 		handler: h,
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// metadata provides route metadata, creating it for route options
+func (r *route) metadata() *context.RouteMetadata {
+	if r.meta == nil {
+		r.meta = &context.RouteMetadata{}
+	}
+
+	return r.meta
+}
+
+// name provides name route was registered with
+func (r *route) name() string {
+	if r.meta == nil {
+		return ""
+	}
+
+	return r.meta.Name
+}
+
+// named checks if route or any of its alternatives was registered with name
+func (r *route) named() bool {
+	if r.name() != "" {
+		return true
+	}
+
+	for _, alternative := range r.alternatives {
+		if alternative.name() != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestPattern provides pattern recorded for request matching route,
+// prefix is a pattern of the route request is mounted under
+func (r *route) requestPattern(prefix string) string {
+	pattern := r.pattern
+	if r.subrouter != nil {
+		pattern = r.mount
+	}
+
+	return prefixPattern(prefix, pattern)
+}
+
+// prefixPattern prefixes pattern with pattern of the route it is mounted under
+func prefixPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+
+	return strings.TrimSuffix(prefix, "/") + pattern
 }
 
 func (r *route) Handler() interface{} {
 	// returns already cached computed handler
 	return r.handler
 }
+
+// routeHandler provides handler composed with middleware attached to route only
+func (r *route) routeHandler() interface{} {
+	return r.middleware.Compose(r.handler)
+}
+
+// hasConstraints checks if route or its alternatives have constraints
+func (r *route) hasConstraints() bool {
+	return len(r.constraints) > 0 || len(r.alternatives) > 0
+}
+
+// MergeRoute merges route registered earlier under the same method and pattern,
+// routes are kept as alternatives when any of them has constraints, otherwise route is replaced
+func (r *route) MergeRoute(existing mux.Route) mux.Route {
+	e, ok := existing.(*route)
+	if !ok || (!e.hasConstraints() && len(r.constraints) == 0) {
+		return r
+	}
+
+	// existing route can be in use by requests, it is copied instead of modified
+	merged := *e
+	merged.alternatives = append(e.alternatives[:len(e.alternatives):len(e.alternatives)], r)
+
+	return &merged
+}
+
+// withChain returns copy of route and its alternatives with handlers composed with middleware,
+// middleware attached to each route only run after them
+func (r *route) withChain(m middleware.Collection, orphanMiddleware bool) *route {
+	c := *r
+	c.chain = m.Compose(r.routeHandler())
+	c.patternValue = c.requestPattern("")
+	c.orphanMiddleware = orphanMiddleware
+	c.alternatives = make([]*route, len(r.alternatives))
+	for i, alternative := range r.alternatives {
+		c.alternatives[i] = alternative.withChain(m, orphanMiddleware)
+	}
+
+	return &c
+}
+
+// matchHTTP selects first route with constraints satisfied by request,
+// the last route without constraints is used as a fallback.
+// unsupported reports if content type was the only reason for request to be rejected
+func (r *route) matchHTTP(req *http.Request) (selected *route, unsupported bool) {
+	if !r.hasConstraints() {
+		return r, false
+	}
+
+	return r.match(func(c constraint) bool { return c.matchHTTP(req) })
+}
+
+// matchFastHTTP selects first route with constraints satisfied by request,
+// the last route without constraints is used as a fallback.
+// unsupported reports if content type was the only reason for request to be rejected
+func (r *route) matchFastHTTP(ctx *fasthttp.RequestCtx) (selected *route, unsupported bool) {
+	if !r.hasConstraints() {
+		return r, false
+	}
+
+	return r.match(func(c constraint) bool { return c.matchFastHTTP(ctx) })
+}
+
+func (r *route) match(matchConstraint func(c constraint) bool) (selected *route, unsupported bool) {
+	var fallback *route
+
+candidates:
+	for i := 0; i <= len(r.alternatives); i++ {
+		candidate := r
+		if i > 0 {
+			candidate = r.alternatives[i-1]
+		}
+
+		if len(candidate.constraints) == 0 {
+			fallback = candidate
+			continue
+		}
+
+		var contentTypeMismatch bool
+		for _, c := range candidate.constraints {
+			if matchConstraint(c) {
+				continue
+			}
+			if c.kind != contentTypeConstraint {
+				continue candidates
+			}
+			contentTypeMismatch = true
+		}
+
+		if !contentTypeMismatch {
+			return candidate, false
+		}
+
+		unsupported = true
+	}
+
+	if fallback != nil {
+		return fallback, false
+	}
+
+	return nil, unsupported
+}
@@ -85,3 +85,64 @@ func TestNilHandler(t *testing.T) {
 		t.Error("Router should panic if handler is nil")
 	}
 }
+
+func TestRouteMatchConstraints(t *testing.T) {
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+	jsonRoute := newRoute(handler, WithContentType("application/json"))
+	csvRoute := newRoute(handler, WithQuery("format", "csv"))
+	v2Route := newRoute(handler, WithHeader("x-api-version", "2"), WithScheme("https"))
+	fallbackRoute := newRoute(handler)
+
+	r := jsonRoute.MergeRoute(fallbackRoute).(*route)
+	r = csvRoute.MergeRoute(r).(*route)
+	r = v2Route.MergeRoute(r).(*route)
+
+	if len(r.alternatives) != 3 || len(fallbackRoute.alternatives) != 0 {
+		t.Fatal("Copy of route registered first should hold alternatives")
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		header      map[string]string
+		want        *route
+		unsupported bool
+	}{
+		{"json", "http://x/y", map[string]string{"Content-Type": "application/json; charset=utf-8"}, jsonRoute, false},
+		{"csv", "http://x/y?format=csv", nil, csvRoute, false},
+		{"v2 https", "https://x/y", map[string]string{"X-Api-Version": "2"}, v2Route, false},
+		{"v2 http", "http://x/y", map[string]string{"X-Api-Version": "2"}, r, false},
+		{"fallback", "http://x/y?format=xml", nil, r, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+
+			got, unsupported := r.matchHTTP(req)
+			if got != tt.want {
+				t.Errorf("matchHTTP() selected wrong route")
+			}
+			if unsupported != tt.unsupported {
+				t.Errorf("matchHTTP() unsupported = %t, want %t", unsupported, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestRouteMergeWithoutConstraints(t *testing.T) {
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+
+	first := newRoute(handler)
+	second := newRoute(handler)
+
+	if second.MergeRoute(first) != second {
+		t.Error("Route without constraints should replace existing route")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4/mux"
 )
 
 // MiddlewareFunc is a http middleware function type
@@ -19,52 +21,91 @@ type Router interface {
 
 	// POST adds http.Handler as router handler
 	// under POST method and given patter
-	POST(pattern string, handler http.Handler)
+	POST(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// GET adds http.Handler as router handler
 	// under GET method and given patter
-	GET(pattern string, handler http.Handler)
+	GET(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// PUT adds http.Handler as router handler
 	// under PUT method and given patter
-	PUT(pattern string, handler http.Handler)
+	PUT(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// DELETE adds http.Handler as router handler
 	// under DELETE method and given patter
-	DELETE(pattern string, handler http.Handler)
+	DELETE(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// PATCH adds http.Handler as router handler
 	// under PATCH method and given patter
-	PATCH(pattern string, handler http.Handler)
+	PATCH(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// OPTIONS adds http.Handler as router handler
 	// under OPTIONS method and given patter
-	OPTIONS(pattern string, handler http.Handler)
+	OPTIONS(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// HEAD adds http.Handler as router handler
 	// under HEAD method and given patter
-	HEAD(pattern string, handler http.Handler)
+	HEAD(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// CONNECT adds http.Handler as router handler
 	// under CONNECT method and given patter
-	CONNECT(pattern string, handler http.Handler)
+	CONNECT(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// TRACE adds http.Handler as router handler
 	// under TRACE method and given patter
-	TRACE(pattern string, handler http.Handler)
+	TRACE(pattern string, handler http.Handler, opts ...RouteOption)
 
 	// USE adds middleware functions ([]MiddlewareFunc)
 	// to whole router branch under given method and patter
 	USE(method, pattern string, fs ...MiddlewareFunc)
 
 	// Handle adds http.Handler as router handler
-	// under given method and patter
-	Handle(method, pattern string, handler http.Handler)
+	// under given method and patter,
+	// patter not starting with slash is prefixed with host e.g. {tenant}.example.com/users.
+	// Panics when Register would return an error
+	Handle(method, pattern string, handler http.Handler, opts ...RouteOption)
+
+	// Register adds http.Handler as router handler like Handle does,
+	// returns error for malformed pattern, pattern conflicting with registered one
+	// and route already registered under given method and pattern
+	Register(method, pattern string, handler http.Handler, opts ...RouteOption) error
+
+	// Remove removes route registered under given method and pattern,
+	// reports if the route was found. Routes can be added and removed
+	// while router is serving requests
+	Remove(method, pattern string) bool
 
 	// Mount another handler as a subrouter
 	Mount(pattern string, handler http.Handler)
 
-	// Compile optimizes Tree nodes reducing static nodes depth when possible
+	// Group calls fn with Router registering routes under prefix,
+	// routes registered within the group are wrapped with fs before their own middleware.
+	// Groups can be nested, routes are added directly to the router
+	Group(prefix string, fn func(Router), fs ...MiddlewareFunc)
+
+	// URL builds URL path for route registered with given name,
+	// params are key value pairs replacing route parameters.
+	// Named routes of mounted Router are looked up as well
+	URL(name string, params ...string) (string, error)
+
+	// Routes lists all registered routes in matching priority order
+	Routes() []RouteInfo
+
+	// Walk calls fn for every registered route in matching priority order,
+	// routes of mounted Router are listed under mount pattern
+	Walk(fn WalkFunc) error
+
+	// Lint reports unreachable and shadowed routes, ambiguous regexp siblings
+	// and middleware registered on paths without routes
+	Lint() []mux.Issue
+
+	// Validate returns *ValidationError listing issues reported by Lint
+	Validate() error
+
+	// Compile optimizes Tree nodes reducing static nodes depth when possible,
+	// routes of fully static patterns are looked up by path without matching the Tree
+	// and sibling regexp parameters are matched in one pass.
+	// Compiled router copies and compiles whole Tree on every change, so it is best called once routes are registered
 	Compile()
 
 	// ServeHTTP dispatches the request to the route handler
@@ -82,6 +123,10 @@ type Router interface {
 	// NotFound replies to the request with the
 	// 405 Error code
 	NotAllowed(http.Handler)
+
+	// PathPolicy sets request path normalization,
+	// trailing slash matching and redirects to canonical path
+	PathPolicy(policy PathPolicy)
 }
 
 // FastHTTPRouter is a fasthttp micro framework, HTTP request router, multiplexer, mux
@@ -91,52 +136,94 @@ type FastHTTPRouter interface {
 
 	// POST adds fasthttp.RequestHandler as router handler
 	// under POST method and given patter
-	POST(pattern string, handler fasthttp.RequestHandler)
+	POST(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// GET adds fasthttp.RequestHandler as router handler
 	// under GET method and given patter
-	GET(pattern string, handler fasthttp.RequestHandler)
+	GET(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// PUT adds fasthttp.RequestHandler as router handler
 	// under PUT method and given patter
-	PUT(pattern string, handler fasthttp.RequestHandler)
+	PUT(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// DELETE adds fasthttp.RequestHandler as router handler
 	// under DELETE method and given patter
-	DELETE(pattern string, handler fasthttp.RequestHandler)
+	DELETE(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// PATCH adds fasthttp.RequestHandler as router handler
 	// under PATCH method and given patter
-	PATCH(pattern string, handler fasthttp.RequestHandler)
+	PATCH(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// OPTIONS adds fasthttp.RequestHandler as router handler
 	// under OPTIONS method and given patter
-	OPTIONS(pattern string, handler fasthttp.RequestHandler)
+	OPTIONS(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// HEAD adds fasthttp.RequestHandler as router handler
 	// under HEAD method and given patter
-	HEAD(pattern string, handler fasthttp.RequestHandler)
+	HEAD(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// CONNECT adds fasthttp.RequestHandler as router handler
 	// under CONNECT method and given patter
-	CONNECT(pattern string, handler fasthttp.RequestHandler)
+	CONNECT(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// TRACE adds fasthttp.RequestHandler as router handler
 	// under TRACE method and given patter
-	TRACE(pattern string, handler fasthttp.RequestHandler)
+	TRACE(pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
 
 	// USE adds middleware functions ([]MiddlewareFunc)
 	// to whole router branch under given method and patter
 	USE(method, pattern string, fs ...FastHTTPMiddlewareFunc)
 
 	// Handle adds fasthttp.RequestHandler as router handler
-	// under given method and patter
-	Handle(method, pattern string, handler fasthttp.RequestHandler)
+	// under given method and patter,
+	// patter not starting with slash is prefixed with host e.g. {tenant}.example.com/users.
+	// Panics when Register would return an error
+	Handle(method, pattern string, handler fasthttp.RequestHandler, opts ...RouteOption)
+
+	// Register adds fasthttp.RequestHandler as router handler like Handle does,
+	// returns error for malformed pattern, pattern conflicting with registered one
+	// and route already registered under given method and pattern
+	Register(method, pattern string, handler fasthttp.RequestHandler, opts ...RouteOption) error
+
+	// Remove removes route registered under given method and pattern,
+	// reports if the route was found. Routes can be added and removed
+	// while router is serving requests
+	Remove(method, pattern string) bool
 
 	// Mount another handler as a subrouter
 	Mount(pattern string, handler fasthttp.RequestHandler)
 
-	// Compile optimizes Tree nodes reducing static nodes depth when possible
+	// MountRouter mounts another FastHTTPRouter as a subrouter,
+	// its routes are listed by Walk and its named routes are built by URL
+	MountRouter(pattern string, router FastHTTPRouter)
+
+	// Group calls fn with FastHTTPRouter registering routes under prefix,
+	// routes registered within the group are wrapped with fs before their own middleware.
+	// Groups can be nested, routes are added directly to the router
+	Group(prefix string, fn func(FastHTTPRouter), fs ...FastHTTPMiddlewareFunc)
+
+	// URL builds URL path for route registered with given name,
+	// params are key value pairs replacing route parameters
+	URL(name string, params ...string) (string, error)
+
+	// Routes lists all registered routes in matching priority order
+	Routes() []RouteInfo
+
+	// Walk calls fn for every registered route in matching priority order,
+	// routes of mounted Router are listed under mount pattern
+	Walk(fn WalkFunc) error
+
+	// Lint reports unreachable and shadowed routes, ambiguous regexp siblings
+	// and middleware registered on paths without routes
+	Lint() []mux.Issue
+
+	// Validate returns *ValidationError listing issues reported by Lint
+	Validate() error
+
+	// Compile optimizes Tree nodes reducing static nodes depth when possible,
+	// routes of fully static patterns are looked up by path without matching the Tree
+	// and sibling regexp parameters are matched in one pass.
+	// Compiled router copies and compiles whole Tree on every change, so it is best called once routes are registered
 	Compile()
 
 	// HandleFastHTTP dispatches the request to the route handler
@@ -154,4 +241,8 @@ type FastHTTPRouter interface {
 	// NotFound replies to the request with the
 	// 405 Error code
 	NotAllowed(fasthttp.RequestHandler)
+
+	// PathPolicy sets request path normalization,
+	// trailing slash matching and redirects to canonical path
+	PathPolicy(policy PathPolicy)
 }
@@ -0,0 +1,358 @@
+package gorouter
+
+import (
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
+	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// snapshot is a routing state published for requests.
+// Router never modifies published snapshot, changes are made to the router Tree
+// and published as a new snapshot, so requests are matched without locking
+type snapshot struct {
+	tree        mux.Tree
+	hostRouting bool
+	policy      PathPolicy
+	// statics holds routes of fully static patterns by method and path,
+	// built for compiled router without host routes
+	statics map[string]map[string]*route
+}
+
+// newSnapshot publishes router Tree which routes hold precomposed middleware chain,
+// compiled router Tree is copied and compiled, routes of the copy are precomposed again
+func newSnapshot(t mux.Tree, compiled, hostRouting bool, policy PathPolicy) *snapshot {
+	tree := t
+
+	var statics map[string]map[string]*route
+	if compiled {
+		tree = t.Clone()
+		compile(tree)
+		precomposeRoots(tree, nil, "", make(orphanIndex))
+
+		if !hostRouting {
+			statics = staticRoutes(tree)
+		}
+	}
+
+	return &snapshot{
+		tree:        tree,
+		hostRouting: hostRouting,
+		policy:      policy,
+		statics:     statics,
+	}
+}
+
+// matchRoute matches path to route of method Node,
+// fully static paths are looked up without matching the Tree
+func (s *snapshot) matchRoute(root mux.Node, path string) (mux.Route, context.Params) {
+	if r, ok := s.statics[root.Name()][path]; ok {
+		return r, nil
+	}
+
+	return root.Tree().MatchRoute(path)
+}
+
+// staticRoutes collects routes of fully static patterns by method and path,
+// route is collected only if matching the Tree resolves it for its path as well
+func staticRoutes(t mux.Tree) map[string]map[string]*route {
+	statics := make(map[string]map[string]*route)
+
+	for _, root := range t {
+		routes := make(map[string]*route)
+
+		_ = root.Tree().Walk(func(branch []mux.Node) error {
+			r, ok := branch[len(branch)-1].Route().(*route)
+			if !ok || !isStaticPattern(r.pattern) {
+				return nil
+			}
+
+			path := pathutils.TrimSlash(r.pattern)
+
+			matched, params := root.Tree().MatchRoute(path)
+			context.ReleaseParams(params)
+
+			if matched == mux.Route(r) {
+				routes[path] = r
+			}
+
+			return nil
+		})
+
+		if len(routes) > 0 {
+			statics[root.Name()] = routes
+		}
+	}
+
+	return statics
+}
+
+func isStaticPattern(pattern string) bool {
+	parts, err := pathutils.Parse(pattern)
+	if err != nil || len(parts) == 0 {
+		return false
+	}
+
+	for _, part := range parts {
+		if !part.IsStatic() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// orphanIndex holds orphan branches by key of the branch they are children of,
+// routes added later to the Tree are marked like precompose marks them
+type orphanIndex map[string][]mux.Node
+
+// treeChange composes handlers of routes affected by router Tree change under path the way precompose does,
+// routes of other branches keep their chains and are shared with published snapshot
+type treeChange struct {
+	host string
+	path string
+	// orphans reports which Nodes on the path below method Node were orphan branches before the change
+	orphans []bool
+}
+
+// newTreeChange prepares change of router Tree under path, it has to be called before the Tree is changed
+func newTreeChange(t mux.Tree, host, path string) treeChange {
+	if host != "" {
+		t = hostTree(t, host)
+	}
+
+	branch := t.Branch(path)
+	orphans := make([]bool, len(branch))
+	for i := 1; i < len(branch); i++ {
+		orphans[i] = isOrphan(branch[i])
+	}
+
+	return treeChange{host: host, path: path, orphans: orphans}
+}
+
+// precompose composes handlers of routes of the changed Node, of its Tree when subtree is set,
+// and of sibling branches of Nodes on the path which became or ceased to be orphan branches.
+// Nodes on the path have to be the ones copied by the Tree modification
+func (c treeChange) precompose(t mux.Tree, orphans orphanIndex, subtree bool) {
+	var m middleware.Collection
+	var key string
+
+	if c.host != "" {
+		node := hostNode(t, c.host)
+		if node == nil {
+			return
+		}
+
+		m = node.Middleware()
+		key = c.host + " "
+		t = node.Tree()
+	}
+
+	var orphanMiddleware bool
+
+	branch := t.Branch(c.path)
+	for i, node := range branch {
+		if i > 0 {
+			wasOrphan := i < len(c.orphans) && c.orphans[i]
+			if orphan := isOrphan(node); orphan != wasOrphan {
+				orphanChanged(branch[i-1], node, orphan, m, orphanMiddleware, key, orphans)
+			}
+
+			orphanMiddleware = orphanMiddleware || sharesPath(node, orphans[key])
+			key += "/"
+		}
+
+		m = append(m[:len(m):len(m)], node.Middleware()...)
+		key += mux.Pattern(node)
+	}
+
+	if len(branch) == 0 {
+		return
+	}
+
+	node := branch[len(branch)-1]
+
+	precomposeRoute(node, m, orphanMiddleware)
+
+	if subtree && len(node.Tree()) > 0 {
+		node.WithChildren(node.Tree().Clone())
+		precompose(node.Tree(), m, orphanMiddleware, key, orphans)
+	}
+}
+
+// orphanChanged updates orphan branches of parent Node Tree after node became or ceased to be orphan branch,
+// sibling branches which requests can match middleware of the node are copied and their routes composed again
+func orphanChanged(parent, node mux.Node, orphan bool, m middleware.Collection, orphanMiddleware bool, key string, orphans orphanIndex) {
+	var siblings []mux.Node
+	for _, sibling := range orphans[key] {
+		if mux.Pattern(sibling) != mux.Pattern(node) {
+			siblings = append(siblings, sibling)
+		}
+	}
+	if orphan {
+		siblings = append(siblings, node)
+	}
+
+	if len(siblings) > 0 {
+		orphans[key] = siblings
+	} else {
+		delete(orphans, key)
+	}
+
+	for _, sibling := range parent.Tree() {
+		if !sharesPath(sibling, []mux.Node{node}) {
+			continue
+		}
+
+		tree, c := parent.Tree().WithCopy(sibling)
+		parent.WithChildren(tree)
+
+		branchMiddleware := append(m[:len(m):len(m)], c.Middleware()...)
+		siblingOrphanMiddleware := orphanMiddleware || sharesPath(c, siblings)
+
+		precomposeRoute(c, branchMiddleware, siblingOrphanMiddleware)
+
+		if len(c.Tree()) > 0 {
+			c.WithChildren(c.Tree().Clone())
+			precompose(c.Tree(), branchMiddleware, siblingOrphanMiddleware, key+"/"+mux.Pattern(c), orphans)
+		}
+	}
+}
+
+// precomposeRoots composes handlers of routes of method Nodes, including the ones under host Nodes,
+// with middleware collected along their branch
+func precomposeRoots(t mux.Tree, m middleware.Collection, key string, orphans orphanIndex) {
+	for _, root := range t {
+		branchMiddleware := make(middleware.Collection, 0, len(m)+len(root.Middleware()))
+		branchMiddleware = append(append(branchMiddleware, m...), root.Middleware()...)
+
+		if _, ok := root.(mux.HostNode); ok {
+			precomposeRoots(root.Tree(), branchMiddleware, root.Name()+" ", orphans)
+			continue
+		}
+
+		precomposeRoute(root, branchMiddleware, false)
+		precompose(root.Tree(), branchMiddleware, false, key+root.Name(), orphans)
+	}
+}
+
+// precompose composes handlers of Tree routes with middleware collected along their branch,
+// routes which requests can match middleware of sibling branch without routes are marked,
+// their chain is composed per request. Orphan branches are collected by key of the Tree branch
+func precompose(t mux.Tree, m middleware.Collection, orphanMiddleware bool, key string, orphans orphanIndex) {
+	siblings := orphanBranches(t)
+	if len(siblings) > 0 {
+		orphans[key] = siblings
+	}
+
+	for _, node := range t {
+		branchMiddleware := make(middleware.Collection, 0, len(m)+len(node.Middleware()))
+		branchMiddleware = append(append(branchMiddleware, m...), node.Middleware()...)
+
+		nodeOrphanMiddleware := orphanMiddleware || sharesPath(node, siblings)
+
+		precomposeRoute(node, branchMiddleware, nodeOrphanMiddleware)
+		precompose(node.Tree(), branchMiddleware, nodeOrphanMiddleware, key+"/"+mux.Pattern(node), orphans)
+	}
+}
+
+// precomposeRoute replaces Node route with a copy holding precomposed chain
+func precomposeRoute(n mux.Node, m middleware.Collection, orphanMiddleware bool) {
+	r, ok := n.Route().(*route)
+	if !ok {
+		return
+	}
+
+	chain := make(middleware.Collection, len(m))
+	copy(chain, m)
+
+	// route is replaced instead of being merged with the one it is copied from
+	n.WithRoute(nil)
+	n.WithRoute(r.withChain(chain.Sort(), orphanMiddleware))
+}
+
+// orphanBranches provides Tree Nodes which branch has middleware but no routes
+func orphanBranches(t mux.Tree) []mux.Node {
+	var orphans []mux.Node
+
+	for _, node := range t {
+		if isOrphan(node) {
+			orphans = append(orphans, node)
+		}
+	}
+
+	return orphans
+}
+
+// isOrphan checks if Node branch has middleware but no routes
+func isOrphan(n mux.Node) bool {
+	return !hasRoute(n) && hasMiddleware(n)
+}
+
+// hasRoute checks if Node or any of its descendants has route
+func hasRoute(n mux.Node) bool {
+	if n.Route() != nil {
+		return true
+	}
+
+	for _, child := range n.Tree() {
+		if hasRoute(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasMiddleware checks if Node or any of its descendants has middleware
+func hasMiddleware(n mux.Node) bool {
+	if len(n.Middleware()) > 0 {
+		return true
+	}
+
+	for _, child := range n.Tree() {
+		if hasMiddleware(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sharesPath checks if any of orphan sibling branches can match path parts matched by Node,
+// static Nodes of different names never match the same path part.
+// Orphan branches are compared by pattern, they can be previous versions of the Node
+func sharesPath(n mux.Node, orphans []mux.Node) bool {
+	for _, orphan := range orphans {
+		if mux.Pattern(orphan) == mux.Pattern(n) {
+			continue
+		}
+
+		if isStaticNode(orphan) && isStaticNode(n) && !strings.EqualFold(orphan.Name(), n.Name()) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func isStaticNode(n mux.Node) bool {
+	return strings.IndexByte(mux.Pattern(n), '{') < 0
+}
+
+// routeMiddleware provides sorted middleware of method Node and its Tree branch matching path,
+// returned collection is a copy so sorting it does not modify published snapshot
+func routeMiddleware(root mux.Node, path string) middleware.Collection {
+	m := make(middleware.Collection, len(root.Middleware()))
+	copy(m, root.Middleware())
+
+	if path != "" {
+		m = m.Merge(root.Tree().MatchMiddleware(path))
+	}
+
+	return m.Sort()
+}
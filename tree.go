@@ -2,6 +2,7 @@ package gorouter
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/vardius/gorouter/v4/mux"
 )
@@ -10,7 +11,7 @@ func allowed(t mux.Tree, method, path string) (allow string) {
 	if path == "*" {
 		// tree roots should be http method nodes only
 		for _, root := range t {
-			if root.Name() == http.MethodOptions {
+			if _, ok := root.(mux.HostNode); ok || root.Name() == http.MethodOptions {
 				continue
 			}
 			if len(allow) == 0 {
@@ -22,7 +23,7 @@ func allowed(t mux.Tree, method, path string) (allow string) {
 	} else {
 		// tree roots should be http method nodes only
 		for _, root := range t {
-			if root.Name() == method || root.Name() == http.MethodOptions {
+			if _, ok := root.(mux.HostNode); ok || root.Name() == method || root.Name() == http.MethodOptions {
 				continue
 			}
 
@@ -40,3 +41,138 @@ func allowed(t mux.Tree, method, path string) (allow string) {
 	}
 	return allow
 }
+
+// splitHostPattern splits pattern into host and path patterns,
+// pattern not starting with slash begins with host e.g. {tenant}.example.com/users
+func splitHostPattern(pattern string) (host, path string) {
+	if pattern == "" || pattern[0] == '/' {
+		return "", pattern
+	}
+
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[:i], pattern[i:]
+	}
+
+	return pattern, ""
+}
+
+// withHost applies fn to the Tree of method nodes for given host,
+// without host fn is applied to the Tree itself
+func withHost(t mux.Tree, host string, fn func(t mux.Tree, maxParamsSize uint8) mux.Tree) mux.Tree {
+	if host == "" {
+		return fn(t, 0)
+	}
+
+	t, node := t.WithHost(host)
+	node.WithChildren(fn(node.Tree(), node.MaxParamsSize()))
+
+	return t
+}
+
+// withoutRoute removes route under path from the Tree of method nodes for given host,
+// host node left without method nodes is removed as well
+func withoutRoute(t mux.Tree, host, path string) (mux.Tree, mux.Route) {
+	if host == "" {
+		return t.WithoutRoute(path)
+	}
+
+	node := hostNode(t, host)
+	if node == nil {
+		return t, nil
+	}
+
+	tree, removed := node.Tree().WithoutRoute(path)
+	if removed == nil {
+		return t, nil
+	}
+
+	t, node = t.WithHost(host)
+	node.WithChildren(tree)
+
+	if len(tree) == 0 && len(node.Middleware()) == 0 {
+		newTree := make(mux.Tree, 0, len(t))
+		for _, root := range t {
+			if root != node {
+				newTree = append(newTree, root)
+			}
+		}
+
+		return newTree, removed
+	}
+
+	return t, removed
+}
+
+// compile optimizes method nodes trees, including the ones under host nodes
+func compile(t mux.Tree) {
+	for _, root := range t {
+		if _, ok := root.(mux.HostNode); ok {
+			compile(root.Tree())
+			continue
+		}
+
+		root.WithChildren(root.Tree().Compile())
+	}
+}
+
+// checkRoute validates route before it is added to the tree,
+// route can be registered under already used method and pattern only if any of them has constraints
+// and constraints of the route differ from the ones of routes already registered there
+func checkRoute(t mux.Tree, host, path string, r *route) error {
+	if host != "" {
+		if err := mux.CheckHostPattern(host); err != nil {
+			return err
+		}
+
+		t = hostTree(t, host)
+	}
+
+	existing, err := t.CheckRoute(path)
+	if err != nil {
+		return err
+	}
+
+	e, ok := existing.(*route)
+	if !ok {
+		return nil
+	}
+
+	if !e.hasConstraints() && len(r.constraints) == 0 {
+		return ErrDuplicateRoute
+	}
+
+	if len(r.constraints) == 0 {
+		return nil
+	}
+
+	if sameConstraints(e.constraints, r.constraints) {
+		return ErrDuplicateRoute
+	}
+	for _, alternative := range e.alternatives {
+		if sameConstraints(alternative.constraints, r.constraints) {
+			return ErrDuplicateRoute
+		}
+	}
+
+	return nil
+}
+
+// hostTree provides Tree of method nodes for host pattern, empty if host is not registered
+func hostTree(t mux.Tree, host string) mux.Tree {
+	if node := hostNode(t, host); node != nil {
+		return node.Tree()
+	}
+
+	return mux.NewTree()
+}
+
+// hostNode provides host node for host pattern, nil if host is not registered
+func hostNode(t mux.Tree, host string) mux.HostNode {
+	for _, root := range t {
+		if node, ok := root.(mux.HostNode); ok && node.Name() == host {
+			return node
+		}
+	}
+
+	return nil
+}
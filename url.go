@@ -0,0 +1,205 @@
+package gorouter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// ErrRouteNotFound is returned when building URL for route name that was not registered
+var ErrRouteNotFound = errors.New("gorouter: route not found")
+
+// urlBuilder is implemented by routers able to build URL for named routes
+type urlBuilder interface {
+	URL(name string, params ...string) (string, error)
+}
+
+type urlChunk struct {
+	// value is a static text or parameter name
+	value    string
+	param    bool
+	catchAll bool
+	exp      string
+	matcher  mux.Matcher
+}
+
+// urlTemplate is a route pattern prepared for URL building
+type urlTemplate struct {
+	pattern string
+	chunks  []urlChunk
+}
+
+func newURLTemplate(pattern string) *urlTemplate {
+	t := &urlTemplate{pattern: pattern}
+
+	// patterns are validated when routes are registered
+	parts, _ := pathutils.Parse(pattern)
+
+	for _, part := range parts {
+		t.chunks = append(t.chunks, urlChunk{value: "/"})
+
+		for _, segment := range part.Segments {
+			if segment.Kind == pathutils.StaticSegment {
+				t.chunks = append(t.chunks, urlChunk{value: segment.Raw})
+				continue
+			}
+
+			c := urlChunk{
+				value:    segment.Name,
+				param:    true,
+				catchAll: segment.Kind == pathutils.CatchAllSegment,
+			}
+			if segment.Exp != "" {
+				c.exp = segment.Exp
+				c.matcher = mux.NewMatcher(segment.Exp)
+			}
+
+			t.chunks = append(t.chunks, c)
+		}
+	}
+
+	return t
+}
+
+// build replaces template parameters with escaped values
+func (t *urlTemplate) build(params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("gorouter.URL: odd number of parameters for %q", t.pattern)
+	}
+
+	if len(t.chunks) == 0 {
+		return "/", nil
+	}
+
+	var b strings.Builder
+	for _, c := range t.chunks {
+		if !c.param {
+			b.WriteString(c.value)
+			continue
+		}
+
+		value, ok := paramValue(c.value, params)
+		if !ok || value == "" {
+			return "", fmt.Errorf("gorouter.URL: missing parameter %q for %q", c.value, t.pattern)
+		}
+
+		if c.matcher != nil && !c.matcher(value) {
+			return "", fmt.Errorf("gorouter.URL: parameter %q value %q does not match %q in %q", c.value, value, c.exp, t.pattern)
+		}
+
+		if c.catchAll {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+
+	return b.String(), nil
+}
+
+func paramValue(key string, params []string) (string, bool) {
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i] == key {
+			return params[i+1], true
+		}
+	}
+
+	return "", false
+}
+
+// namedRoutes maps route names to URL templates
+type namedRoutes map[string]*urlTemplate
+
+func (n namedRoutes) add(name, pattern string) {
+	if err := n.check(name, pattern); err != nil {
+		panic(fmt.Sprintf("gorouter: %s", err))
+	}
+
+	if _, ok := n[name]; !ok {
+		n[name] = newURLTemplate(pattern)
+	}
+}
+
+// check reports if route name is already used for another pattern
+func (n namedRoutes) check(name, pattern string) error {
+	if t, ok := n[name]; ok && t.pattern != pattern {
+		return fmt.Errorf("%w: route name %q already used for %q", ErrDuplicateRoute, name, t.pattern)
+	}
+
+	return nil
+}
+
+// prune removes names no longer used by any route within the Tree
+func (n namedRoutes) prune(t mux.Tree) {
+	used := make(map[string]bool)
+
+	_ = t.Walk(func(branch []mux.Node) error {
+		if r, ok := branch[len(branch)-1].Route().(*route); ok {
+			used[r.name()] = true
+			for _, alternative := range r.alternatives {
+				used[alternative.name()] = true
+			}
+		}
+
+		return nil
+	})
+
+	for name := range n {
+		if !used[name] {
+			delete(n, name)
+		}
+	}
+}
+
+// mountedRouter is a handler mounted as a subrouter under pattern
+type mountedRouter struct {
+	template *urlTemplate
+	handler  interface{}
+}
+
+// buildURL builds URL path for named route, looking through mounted subrouters
+// when route was not registered directly
+func buildURL(names namedRoutes, mounts []mountedRouter, name string, params ...string) (string, error) {
+	if t, ok := names[name]; ok {
+		return t.build(params...)
+	}
+
+	for _, m := range mounts {
+		sub, ok := m.handler.(urlBuilder)
+		if !ok {
+			continue
+		}
+
+		subPath, err := sub.URL(name, params...)
+		if errors.Is(err, ErrRouteNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		prefix, err := m.template.build(params...)
+		if err != nil {
+			return "", err
+		}
+
+		if subPath == "/" {
+			return prefix, nil
+		}
+		if prefix == "/" {
+			return subPath, nil
+		}
+
+		return prefix + subPath, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+}
@@ -0,0 +1,58 @@
+package gorouter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestURLTemplateBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		params  []string
+		want    string
+		wantErr bool
+	}{
+		{"root", "/", nil, "/", false},
+		{"static", "/x/y", nil, "/x/y", false},
+		{"wildcard", "/blog/{postsId}", []string{"postsId", "42"}, "/blog/42", false},
+		{"escaped", "/hello/{name}", []string{"name", "a b/c"}, "/hello/a%20b%2Fc", false},
+		{"regexp", "/x/{id:[0-9]+}", []string{"id", "12"}, "/x/12", false},
+		{"regexp mismatch", "/x/{id:[0-9]+}", []string{"id", "ab"}, "", true},
+		{"embedded", "/reports/{year}-{month}.{format}", []string{"year", "2020", "month", "01", "format", "csv"}, "/reports/2020-01.csv", false},
+		{"catch-all", "/files/{path...}", []string{"path", "css/main file.css"}, "/files/css/main%20file.css", false},
+		{"missing", "/blog/{postsId}", []string{"id", "42"}, "", true},
+		{"odd", "/blog/{postsId}", []string{"postsId"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newURLTemplate(tt.pattern).build(tt.params...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamedRoutesDuplicate(t *testing.T) {
+	names := make(namedRoutes)
+	names.add("x", "/x")
+	names.add("x", "/x")
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("Reusing route name for another pattern should panic")
+		}
+	}()
+
+	names.add("x", "/y")
+}
+
+func TestBuildURLNotFound(t *testing.T) {
+	if _, err := buildURL(make(namedRoutes), nil, "x"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected ErrRouteNotFound, got: %v", err)
+	}
+}
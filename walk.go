@@ -0,0 +1,202 @@
+package gorouter
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
+	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// RouteInfo describes route registered in the router
+type RouteInfo struct {
+	// Method is HTTP method route is registered under
+	Method string
+	// Host is a host pattern, empty when route matches any host
+	Host string
+	// Pattern is a full path pattern, e.g. /users/{id:int}
+	Pattern string
+	// Name route was registered with
+	Name string
+	// Metadata route was registered with, Name included
+	Metadata context.RouteMetadata
+	// Params are the host and path parameters
+	Params []ParamInfo
+	// Constraints describe request constraints, e.g. header X-Api-Version=2
+	Constraints []string
+	// Handler is http.Handler or fasthttp.RequestHandler
+	Handler interface{}
+	// Middleware attached along route branch and to route only, in execution order
+	Middleware middleware.Collection
+	// Mounted reports if route is a mounted handler, which routes can not be listed
+	Mounted bool
+}
+
+// ParamInfo describes host or path parameter of the route
+type ParamInfo struct {
+	// Name of the parameter
+	Name string
+	// Exp is a matcher name, e.g. int or uuid, or regexp parameter value has to match,
+	// empty when parameter matches any value
+	Exp string
+	// CatchAll reports if parameter matches the rest of the path
+	CatchAll bool
+}
+
+// String describes parameter as in the pattern without braces, e.g. id:int or path...
+func (p ParamInfo) String() string {
+	switch {
+	case p.Exp != "":
+		return p.Name + ":" + p.Exp
+	case p.CatchAll:
+		return p.Name + "..."
+	}
+
+	return p.Name
+}
+
+// WalkFunc is called for every route registered in the router,
+// returning SkipAll stops walking without an error
+type WalkFunc func(route RouteInfo) error
+
+// SkipAll is used as a return value from WalkFunc to stop walking
+var SkipAll = errors.New("gorouter: skip all routes")
+
+// walker is implemented by routers able to enumerate their routes
+type walker interface {
+	Walk(fn WalkFunc) error
+}
+
+// walk calls fn for every route of method nodes tree, including the ones under host nodes
+func walk(t mux.Tree, fn WalkFunc) error {
+	err := walkTree(t, "", fn)
+	if errors.Is(err, SkipAll) {
+		return nil
+	}
+
+	return err
+}
+
+func walkTree(t mux.Tree, host string, fn WalkFunc) error {
+	for _, root := range t {
+		if node, ok := root.(mux.HostNode); ok {
+			if err := walkTree(node.Tree(), node.Name(), fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		method := root.Name()
+
+		if err := walkRoute(method, host, "/", root.Middleware(), root.Route(), fn); err != nil {
+			return err
+		}
+
+		err := root.Tree().Walk(func(branch []mux.Node) error {
+			node := branch[len(branch)-1]
+			if node.Route() == nil {
+				return nil
+			}
+
+			parts := make([]string, len(branch))
+			m := append(middleware.Collection{}, root.Middleware()...)
+			for i, n := range branch {
+				parts[i] = mux.Pattern(n)
+				m = m.Merge(n.Middleware())
+			}
+
+			return walkRoute(method, host, "/"+strings.Join(parts, "/"), m, node.Route(), fn)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkRoute calls fn for route and its alternatives,
+// routes of mounted router are listed under given pattern
+func walkRoute(method, host, pattern string, m middleware.Collection, r mux.Route, fn WalkFunc) error {
+	rt, ok := r.(*route)
+	if !ok {
+		return nil
+	}
+
+	if rt.router != nil {
+		if sub, ok := rt.router.(walker); ok {
+			return sub.Walk(func(info RouteInfo) error {
+				if info.Method != method {
+					return nil
+				}
+
+				info.Host = host
+				info.Pattern = strings.TrimSuffix(pattern, "/") + info.Pattern
+				info.Params = append(patternParams(host, pattern), info.Params...)
+				info.Middleware = append(append(append(middleware.Collection{}, m...), rt.middleware...), info.Middleware...).Sort()
+
+				return fn(info)
+			})
+		}
+	}
+
+	for i := 0; i <= len(rt.alternatives); i++ {
+		candidate := rt
+		if i > 0 {
+			candidate = rt.alternatives[i-1]
+		}
+
+		info := RouteInfo{
+			Method:     method,
+			Host:       host,
+			Pattern:    pattern,
+			Name:       candidate.name(),
+			Params:     patternParams(host, pattern),
+			Handler:    candidate.handler,
+			Middleware: append(append(middleware.Collection{}, m...).Sort(), candidate.middleware...),
+			Mounted:    candidate.subrouter != nil,
+		}
+		if candidate.meta != nil {
+			info.Metadata = *candidate.meta
+		}
+		for _, c := range candidate.constraints {
+			info.Constraints = append(info.Constraints, c.String())
+		}
+		if candidate.subrouter != nil {
+			info.Handler = candidate.subrouter
+		}
+
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// patternParams describes host and path pattern parameters
+func patternParams(host, pattern string) []ParamInfo {
+	// patterns are validated when routes are registered
+	parts, _ := pathutils.Parse(pattern)
+	if host != "" {
+		hostPart, _ := pathutils.ParsePart(host)
+		parts = append([]pathutils.Part{hostPart}, parts...)
+	}
+
+	var params []ParamInfo
+	for _, part := range parts {
+		for _, segment := range part.Segments {
+			if segment.Kind != pathutils.StaticSegment {
+				params = append(params, ParamInfo{
+					Name:     segment.Name,
+					Exp:      segment.Exp,
+					CatchAll: segment.Kind == pathutils.CatchAllSegment,
+				})
+			}
+		}
+	}
+
+	return params
+}